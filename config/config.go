@@ -214,3 +214,53 @@ func (c *Config) GetInt(s string) int {
 	c.bindEnv(s)
 	return c.v.GetInt(s)
 }
+
+// RedactedValue replaces sensitive values in Redacted
+const RedactedValue = "[REDACTED]"
+
+var sensitiveKeyParts = []string{"secret", "password", "token", "key", "dsn", "cert", "credential"}
+
+// Redacted returns all effective config values with anything sensitive redacted. Values under
+// `secrets`, values loaded from secrets and keys that look sensitive are replaced.
+func (c *Config) Redacted() map[string]interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	secretPaths := map[string]struct{}{}
+	for _, path := range c.v.GetStringMapString("secrets") {
+		secretPaths[strings.ToLower(path)] = struct{}{}
+	}
+
+	settings := c.v.AllSettings()
+	redactSettings(settings, "", secretPaths)
+	return settings
+}
+
+func redactSettings(settings map[string]interface{}, prefix string, secretPaths map[string]struct{}) {
+	for k, v := range settings {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		_, isSecret := secretPaths[path]
+		if isSecret || path == "secrets" || isSensitiveKey(k) {
+			settings[k] = RedactedValue
+			continue
+		}
+
+		if sub, ok := v.(map[string]interface{}); ok {
+			redactSettings(sub, path, secretPaths)
+		}
+	}
+}
+
+func isSensitiveKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(k, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,343 @@
+package grpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ConradKurth/gokit/config"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	// registers the client side health checking
+	_ "google.golang.org/grpc/health"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	defaultLoadBalancing    = "round_robin"
+	defaultKeepaliveTimeout = 10 * time.Second
+	defaultMaxAttempts      = 3
+	defaultInitialBackoff   = 100 * time.Millisecond
+	defaultMaxBackoff       = time.Second
+	defaultBackoffFactor    = 2
+)
+
+// ErrClosed is returned when a connection is requested from a closed registry
+var ErrClosed = errors.New("grpc client registry is closed")
+
+// RetryPolicy is the retry policy of an upstream, see https://github.com/grpc/proposal/blob/master/A6-client-retries.md
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Codes          []string
+}
+
+// Upstream describes a named grpc service the service calls
+type Upstream struct {
+	Name   string
+	Target string
+	// Timeout is the deadline of calls that have none
+	Timeout time.Duration
+	// Keepalive is the interval of keepalive pings, they are off when it is zero
+	Keepalive        time.Duration
+	KeepaliveTimeout time.Duration
+	LoadBalancing    string
+	// HealthCheck only uses backends that report serving through the grpc health service
+	HealthCheck bool
+	Retry       RetryPolicy
+}
+
+// UpstreamFromConfig reads the upstream from `grpc.clients.<name>`. Keepalive is off unless
+// `keepaliveSecs` is set, the upstream has to permit pings that often. Retries can be
+// disabled with a `retry.maxAttempts` of 1.
+func UpstreamFromConfig(c *config.Config, name string) Upstream {
+	path := "grpc.clients." + name
+	u := Upstream{
+		Name:             name,
+		Target:           c.GetString(path + ".target"),
+		Timeout:          time.Duration(c.GetInt(path+".timeoutMs")) * time.Millisecond,
+		Keepalive:        time.Duration(c.GetInt(path+".keepaliveSecs")) * time.Second,
+		KeepaliveTimeout: time.Duration(c.GetInt(path+".keepaliveTimeoutSecs")) * time.Second,
+		LoadBalancing:    c.GetString(path + ".loadBalancing"),
+		HealthCheck:      c.GetBool(path + ".healthCheck"),
+		Retry: RetryPolicy{
+			MaxAttempts:    c.GetInt(path + ".retry.maxAttempts"),
+			InitialBackoff: time.Duration(c.GetInt(path+".retry.initialBackoffMs")) * time.Millisecond,
+			MaxBackoff:     time.Duration(c.GetInt(path+".retry.maxBackoffMs")) * time.Millisecond,
+			Multiplier:     c.GetFloat64(path + ".retry.multiplier"),
+			Codes:          c.GetStringSlice(path + ".retry.codes"),
+		},
+	}
+	return u.withDefaults()
+}
+
+func (u Upstream) withDefaults() Upstream {
+	if u.Keepalive > 0 && u.KeepaliveTimeout == 0 {
+		u.KeepaliveTimeout = defaultKeepaliveTimeout
+	}
+	if u.LoadBalancing == "" {
+		u.LoadBalancing = defaultLoadBalancing
+	}
+	if u.Retry.MaxAttempts == 0 {
+		u.Retry.MaxAttempts = defaultMaxAttempts
+	}
+	if u.Retry.InitialBackoff == 0 {
+		u.Retry.InitialBackoff = defaultInitialBackoff
+	}
+	if u.Retry.MaxBackoff == 0 {
+		u.Retry.MaxBackoff = defaultMaxBackoff
+	}
+	if u.Retry.Multiplier == 0 {
+		u.Retry.Multiplier = defaultBackoffFactor
+	}
+	if len(u.Retry.Codes) == 0 {
+		u.Retry.Codes = []string{"UNAVAILABLE"}
+	}
+	return u
+}
+
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+	HealthCheckConfig   *healthCheckConfig    `json:"healthCheckConfig,omitempty"`
+	MethodConfig        []methodConfig        `json:"methodConfig,omitempty"`
+}
+
+type healthCheckConfig struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type methodConfig struct {
+	Name        []struct{}   `json:"name"`
+	RetryPolicy *retryPolicy `json:"retryPolicy"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// ServiceConfig returns the grpc service config json of the upstream
+func (u Upstream) ServiceConfig() (string, error) {
+	sc := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{u.LoadBalancing: {}}},
+	}
+	if u.HealthCheck {
+		// the empty service name is the overall health of the server
+		sc.HealthCheckConfig = &healthCheckConfig{}
+	}
+	if u.Retry.MaxAttempts > 1 {
+		sc.MethodConfig = []methodConfig{{
+			// an empty name matches all methods
+			Name: []struct{}{{}},
+			RetryPolicy: &retryPolicy{
+				MaxAttempts:          u.Retry.MaxAttempts,
+				InitialBackoff:       fmt.Sprintf("%gs", u.Retry.InitialBackoff.Seconds()),
+				MaxBackoff:           fmt.Sprintf("%gs", u.Retry.MaxBackoff.Seconds()),
+				BackoffMultiplier:    u.Retry.Multiplier,
+				RetryableStatusCodes: u.Retry.Codes,
+			},
+		}}
+	}
+
+	b, err := json.Marshal(sc)
+	if err != nil {
+		return "", fmt.Errorf("marshaling service config: %w", err)
+	}
+	return string(b), nil
+}
+
+// CredentialsFunc returns the transport credentials of an upstream
+type CredentialsFunc func(name string) (credentials.TransportCredentials, error)
+
+type options struct {
+	credentials        CredentialsFunc
+	unaryInterceptors  map[string][]grpc.UnaryClientInterceptor
+	streamInterceptors map[string][]grpc.StreamClientInterceptor
+	dialOptions        map[string][]grpc.DialOption
+}
+
+// Option configures the registry
+type Option func(*options)
+
+// WithCredentials sets the transport credentials of the upstreams. Without it connections
+// are plaintext.
+func WithCredentials(fn CredentialsFunc) Option {
+	return func(o *options) {
+		o.credentials = fn
+	}
+}
+
+// WithUnaryInterceptors adds unary interceptors to the connection of the upstream
+func WithUnaryInterceptors(name string, i ...grpc.UnaryClientInterceptor) Option {
+	return func(o *options) {
+		o.unaryInterceptors[name] = append(o.unaryInterceptors[name], i...)
+	}
+}
+
+// WithStreamInterceptors adds stream interceptors to the connection of the upstream
+func WithStreamInterceptors(name string, i ...grpc.StreamClientInterceptor) Option {
+	return func(o *options) {
+		o.streamInterceptors[name] = append(o.streamInterceptors[name], i...)
+	}
+}
+
+// WithDialOptions adds dial options to the connection of the upstream
+func WithDialOptions(name string, d ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions[name] = append(o.dialOptions[name], d...)
+	}
+}
+
+// Registry holds the connections to the upstreams of a service
+type Registry struct {
+	cfg  *config.Config
+	opts options
+
+	lock   sync.Mutex
+	conns  map[string]*grpc.ClientConn
+	closed bool
+}
+
+// NewRegistry returns a registry for the upstreams configured under `grpc.clients`
+func NewRegistry(c *config.Config, opts ...Option) *Registry {
+	o := options{
+		credentials: func(string) (credentials.TransportCredentials, error) {
+			return insecure.NewCredentials(), nil
+		},
+		unaryInterceptors:  map[string][]grpc.UnaryClientInterceptor{},
+		streamInterceptors: map[string][]grpc.StreamClientInterceptor{},
+		dialOptions:        map[string][]grpc.DialOption{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Registry{
+		cfg:   c,
+		opts:  o,
+		conns: map[string]*grpc.ClientConn{},
+	}
+}
+
+// Get returns the connection to the named upstream, it is created on first use.
+func (r *Registry) Get(name string) (*grpc.ClientConn, error) {
+	u := UpstreamFromConfig(r.cfg, name)
+	if u.Target == "" {
+		return nil, fmt.Errorf("grpc upstream %q has no grpc.clients.%s.target", name, name)
+	}
+	return r.get(name, u)
+}
+
+// Dial returns a connection to a target that is not configured as an upstream. It keeps the
+// defaults of grpc.Dial for existing callers: pick_first, no retries and the passthrough
+// resolver for targets without a scheme.
+func (r *Registry) Dial(target string) (*grpc.ClientConn, error) {
+	return r.get(target, legacyUpstream(target))
+}
+
+func legacyUpstream(target string) Upstream {
+	u := Upstream{
+		Name:          target,
+		Target:        target,
+		LoadBalancing: "pick_first",
+		Retry:         RetryPolicy{MaxAttempts: 1},
+	}
+	// grpc.NewClient resolves targets without a registered scheme with dns instead
+	if parsed, err := url.Parse(target); err != nil || resolver.Get(parsed.Scheme) == nil {
+		u.Target = "passthrough:///" + target
+	}
+	return u
+}
+
+func (r *Registry) get(key string, u Upstream) (*grpc.ClientConn, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+	if c, ok := r.conns[key]; ok {
+		return c, nil
+	}
+
+	c, err := r.newConn(u)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to grpc upstream %q: %w", u.Name, err)
+	}
+	r.conns[key] = c
+	return c, nil
+}
+
+func (r *Registry) newConn(u Upstream) (*grpc.ClientConn, error) {
+	creds, err := r.opts.credentials(u.Name)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := u.ServiceConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	unary := []grpc.UnaryClientInterceptor{otelgrpc.UnaryClientInterceptor()}
+	if u.Timeout > 0 {
+		unary = append(unary, timeoutInterceptor(u.Timeout))
+	}
+	unary = append(unary, r.opts.unaryInterceptors[u.Name]...)
+	stream := append([]grpc.StreamClientInterceptor{otelgrpc.StreamClientInterceptor()}, r.opts.streamInterceptors[u.Name]...)
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(sc),
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}
+	if u.Keepalive > 0 {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    u.Keepalive,
+			Timeout: u.KeepaliveTimeout,
+		}))
+	}
+	dialOpts = append(dialOpts, r.opts.dialOptions[u.Name]...)
+
+	return grpc.NewClient(u.Target, dialOpts...)
+}
+
+// timeoutInterceptor sets the default deadline on calls without one
+func timeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// Close closes all connections, the registry can not be used afterwards
+func (r *Registry) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.closed = true
+	var allErrs error
+	for key, c := range r.conns {
+		if err := c.Close(); err != nil {
+			allErrs = errors.Join(allErrs, fmt.Errorf("closing grpc upstream %q: %w", key, err))
+		}
+	}
+	r.conns = map[string]*grpc.ClientConn{}
+	return allErrs
+}
@@ -0,0 +1,179 @@
+package grpcclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func newTestServer(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go func() {
+		_ = s.Serve(ln)
+	}()
+	t.Cleanup(s.Stop)
+	return ln.Addr().String()
+}
+
+func TestUpstream_ServiceConfig(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Config   map[string]interface{}
+		Expected string
+	}{
+		{
+			Name: "defaults",
+			Config: map[string]interface{}{
+				"grpc.clients.users.target": "users:8080",
+			},
+			Expected: `{"loadBalancingConfig":[{"round_robin":{}}],"methodConfig":[{"name":[{}],"retryPolicy":{"maxAttempts":3,"initialBackoff":"0.1s","maxBackoff":"1s","backoffMultiplier":2,"retryableStatusCodes":["UNAVAILABLE"]}}]}`,
+		},
+		{
+			Name: "health checked without retries",
+			Config: map[string]interface{}{
+				"grpc.clients.users.target":            "users:8080",
+				"grpc.clients.users.loadBalancing":     "pick_first",
+				"grpc.clients.users.healthCheck":       true,
+				"grpc.clients.users.retry.maxAttempts": 1,
+			},
+			Expected: `{"loadBalancingConfig":[{"pick_first":{}}],"healthCheckConfig":{"serviceName":""}}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			u := UpstreamFromConfig(config.LoadConfig(config.WithMap(tc.Config)), "users")
+			sc, err := u.ServiceConfig()
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.Expected, sc)
+		})
+	}
+}
+
+func TestUpstreamFromConfig_Keepalive(t *testing.T) {
+	tt := []struct {
+		Name             string
+		Config           map[string]interface{}
+		Keepalive        time.Duration
+		KeepaliveTimeout time.Duration
+	}{
+		{
+			Name: "off by default",
+			Config: map[string]interface{}{
+				"grpc.clients.users.target": "users:8080",
+			},
+		},
+		{
+			Name: "opt in",
+			Config: map[string]interface{}{
+				"grpc.clients.users.target":        "users:8080",
+				"grpc.clients.users.keepaliveSecs": 60,
+			},
+			Keepalive:        time.Minute,
+			KeepaliveTimeout: 10 * time.Second,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			u := UpstreamFromConfig(config.LoadConfig(config.WithMap(tc.Config)), "users")
+			assert.Equal(t, tc.Keepalive, u.Keepalive)
+			assert.Equal(t, tc.KeepaliveTimeout, u.KeepaliveTimeout)
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	addr := newTestServer(t)
+	r := NewRegistry(config.LoadConfig(config.WithMap(map[string]interface{}{
+		"grpc.clients.users.target":      addr,
+		"grpc.clients.users.healthCheck": true,
+		"grpc.clients.users.timeoutMs":   1000,
+	})))
+
+	_, err := r.Get("orders")
+	assert.EqualError(t, err, `grpc upstream "orders" has no grpc.clients.orders.target`)
+
+	conn, err := r.Get("users")
+	require.NoError(t, err)
+	again, err := r.Get("users")
+	require.NoError(t, err)
+	assert.Same(t, conn, again)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	require.NoError(t, r.Close())
+	_, err = r.Get("users")
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestRegistry_FailedDialNotCached(t *testing.T) {
+	r := NewRegistry(config.LoadConfig(config.WithMap(map[string]interface{}{
+		"grpc.clients.users.target":        "127.0.0.1:1",
+		"grpc.clients.users.loadBalancing": "does_not_exist",
+	})))
+
+	_, err := r.Get("users")
+	assert.Error(t, err)
+	assert.Empty(t, r.conns)
+}
+
+func TestRegistry_Dial(t *testing.T) {
+	addr := newTestServer(t)
+	r := NewRegistry(config.LoadConfig(config.WithMap(nil)))
+	defer r.Close()
+
+	conn, err := r.Dial(addr)
+	require.NoError(t, err)
+	assert.Equal(t, "passthrough:///"+addr, conn.Target())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	conn, err = r.Dial("dns:///" + addr)
+	require.NoError(t, err)
+	assert.Equal(t, "dns:///"+addr, conn.Target())
+
+	// the defaults of grpc.Dial: pick_first without retries
+	sc, err := legacyUpstream(addr).ServiceConfig()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"loadBalancingConfig":[{"pick_first":{}}]}`, sc)
+}
+
+func TestTimeoutInterceptor(t *testing.T) {
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+		return nil
+	}
+
+	i := timeoutInterceptor(time.Minute)
+	require.NoError(t, i(context.Background(), "/test/Do", nil, nil, nil, invoker))
+
+	// an existing deadline is kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	require.NoError(t, i(ctx, "/test/Do", nil, nil, nil, invoker))
+}
@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.temporal.io/sdk/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// Pinger is implemented by clients that can ping their backend, e.g. a memcache client
+type Pinger interface {
+	Ping() error
+}
+
+// SQLX returns a check that pings the database
+func SQLX(db *sqlx.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// Memcache returns a check that pings the memcache servers
+func Memcache(c Pinger) CheckFunc {
+	return func(context.Context) error {
+		return c.Ping()
+	}
+}
+
+// Temporal returns a check that calls the temporal health endpoint
+func Temporal(c client.Client) CheckFunc {
+	return func(ctx context.Context) error {
+		_, err := c.CheckHealth(ctx, &client.CheckHealthRequest{})
+		return err
+	}
+}
+
+// GRPCConn returns a check that fails if the connection is in a failure or shutdown state.
+// Idle connections are asked to reconnect.
+func GRPCConn(conn *grpc.ClientConn) CheckFunc {
+	return func(context.Context) error {
+		switch state := conn.GetState(); state {
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			return fmt.Errorf("grpc connection to %s is %s", conn.Target(), state)
+		case connectivity.Idle:
+			conn.Connect()
+		}
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const watchInterval = time.Second * 5
+
+// RegisterGRPC registers the standard grpc.health.v1 service backed by the registry.
+// The empty service name reports readiness, any other name reports the check with that name.
+func (r *Registry) RegisterGRPC(s *grpc.Server) {
+	grpc_health_v1.RegisterHealthServer(s, &grpcServer{registry: r})
+}
+
+type grpcServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	registry *Registry
+}
+
+func (s *grpcServer) servingStatus(ctx context.Context, service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, error) {
+	if service == "" {
+		if s.registry.Readiness(ctx).Status == StatusFailing {
+			return grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil
+		}
+		return grpc_health_v1.HealthCheckResponse_SERVING, nil
+	}
+
+	res, ok := s.registry.Check(ctx, service)
+	if !ok {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, status.Errorf(codes.NotFound, "unknown service %s", service)
+	}
+	if res.Status != StatusOK {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING, nil
+}
+
+// Check implements grpc_health_v1.HealthServer
+func (s *grpcServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	st, err := s.servingStatus(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch implements grpc_health_v1.HealthServer. It sends the status whenever it changes.
+func (s *grpcServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_ServingStatus(-1)
+	for {
+		// unknown services are reported as such rather than failing the stream
+		st, _ := s.servingStatus(ctx, req.GetService())
+		if st != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
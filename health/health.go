@@ -0,0 +1,266 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status is the outcome of a health check or a report
+type Status string
+
+const (
+	// StatusOK means the check passed
+	StatusOK Status = "ok"
+	// StatusDegraded means only non-critical checks failed
+	StatusDegraded Status = "degraded"
+	// StatusFailing means at least one critical check failed
+	StatusFailing Status = "failing"
+
+	defaultTimeout  = time.Second * 2
+	defaultCacheTTL = time.Second
+
+	drainingCheck = "draining"
+)
+
+// CheckFunc checks a single dependency and returns an error if it is unhealthy
+type CheckFunc func(ctx context.Context) error
+
+// Result is the result of a single check
+type Result struct {
+	Name       string    `json:"name"`
+	Status     Status    `json:"status"`
+	Critical   bool      `json:"critical"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report is the aggregated result of a set of checks
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+type checkOptions struct {
+	critical bool
+	liveness bool
+	timeout  time.Duration
+	cacheTTL time.Duration
+}
+
+// NonCritical marks a check as non-critical. A failing non-critical check degrades
+// the report but does not fail it.
+func NonCritical() func(*checkOptions) {
+	return func(o *checkOptions) {
+		o.critical = false
+	}
+}
+
+// Liveness will also run the check for the liveness probe. Only use this for checks
+// where a restart of the process would fix the failure.
+func Liveness() func(*checkOptions) {
+	return func(o *checkOptions) {
+		o.liveness = true
+	}
+}
+
+// Timeout sets how long a single check run may take
+func Timeout(d time.Duration) func(*checkOptions) {
+	return func(o *checkOptions) {
+		o.timeout = d
+	}
+}
+
+// CacheTTL sets how long the result of a check is reused before it is run again
+func CacheTTL(d time.Duration) func(*checkOptions) {
+	return func(o *checkOptions) {
+		o.cacheTTL = d
+	}
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+	opts checkOptions
+
+	lock   sync.Mutex
+	last   Result
+	hasRun bool
+}
+
+// run will run the check or return the cached result if it is still fresh
+func (c *check) run(ctx context.Context) Result {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.hasRun && time.Since(c.last.CheckedAt) < c.opts.cacheTTL {
+		return c.last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.opts.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runCheck(ctx, c.fn)
+	res := Result{
+		Name:       c.name,
+		Status:     StatusOK,
+		Critical:   c.opts.critical,
+		DurationMs: time.Since(start).Milliseconds(),
+		CheckedAt:  start,
+	}
+	if err != nil {
+		res.Status = StatusFailing
+		res.Error = err.Error()
+	}
+
+	c.last = res
+	c.hasRun = true
+	return res
+}
+
+// runCheck runs the check function while honoring the timeout, even if the check
+// itself ignores the context.
+func runCheck(ctx context.Context, fn CheckFunc) error {
+	errs := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errs <- fmt.Errorf("panic in health check: %v", r)
+			}
+		}()
+		errs <- fn(ctx)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("health check timed out: %w", ctx.Err())
+	}
+}
+
+// Registry holds all registered health checks
+type Registry struct {
+	lock     sync.RWMutex
+	checks   []*check
+	draining atomic.Bool
+}
+
+// New returns a new empty registry
+func New() *Registry {
+	return &Registry{}
+}
+
+// Register registers a named check. Checks are critical by default.
+func (r *Registry) Register(name string, fn CheckFunc, opts ...func(*checkOptions)) {
+	o := checkOptions{
+		critical: true,
+		timeout:  defaultTimeout,
+		cacheTTL: defaultCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i, c := range r.checks {
+		if c.name == name {
+			r.checks[i] = &check{name: name, fn: fn, opts: o}
+			return
+		}
+	}
+	r.checks = append(r.checks, &check{name: name, fn: fn, opts: o})
+}
+
+// Liveness runs all checks registered for liveness
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.report(ctx, func(c *check) bool {
+		return c.opts.liveness
+	})
+}
+
+// Readiness runs all registered checks. It always fails while the registry is draining.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	report := r.report(ctx, func(*check) bool {
+		return true
+	})
+	if r.IsDraining() {
+		report.Status = StatusFailing
+		report.Checks = append(report.Checks, Result{
+			Name:      drainingCheck,
+			Status:    StatusFailing,
+			Critical:  true,
+			Error:     "service is shutting down",
+			CheckedAt: time.Now(),
+		})
+	}
+	return report
+}
+
+// Drain marks the service as shutting down, failing the readiness probe so
+// load balancers stop sending new traffic.
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// IsDraining returns if the registry has been drained
+func (r *Registry) IsDraining() bool {
+	return r.draining.Load()
+}
+
+// Check runs a single named check
+func (r *Registry) Check(ctx context.Context, name string) (Result, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, c := range r.checks {
+		if c.name == name {
+			return c.run(ctx), true
+		}
+	}
+	return Result{}, false
+}
+
+func (r *Registry) report(ctx context.Context, filter func(*check) bool) Report {
+	r.lock.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if filter(c) {
+			checks = append(checks, c)
+		}
+	}
+	r.lock.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: results,
+	}
+	for _, res := range results {
+		if res.Status == StatusOK {
+			continue
+		}
+		if res.Critical {
+			report.Status = StatusFailing
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
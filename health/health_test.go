@@ -0,0 +1,151 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func ok(context.Context) error {
+	return nil
+}
+
+func failing(context.Context) error {
+	return errors.New("down")
+}
+
+func Test_Readiness(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Register func(r *Registry)
+		Expected Status
+		Code     int
+	}{
+		{
+			Name:     "No checks",
+			Register: func(*Registry) {},
+			Expected: StatusOK,
+			Code:     http.StatusOK,
+		},
+		{
+			Name: "All checks pass",
+			Register: func(r *Registry) {
+				r.Register("db", ok)
+				r.Register("cache", ok, NonCritical())
+			},
+			Expected: StatusOK,
+			Code:     http.StatusOK,
+		},
+		{
+			Name: "Non critical check fails",
+			Register: func(r *Registry) {
+				r.Register("db", ok)
+				r.Register("cache", failing, NonCritical())
+			},
+			Expected: StatusDegraded,
+			Code:     http.StatusOK,
+		},
+		{
+			Name: "Critical check fails",
+			Register: func(r *Registry) {
+				r.Register("cache", failing, NonCritical())
+				r.Register("db", failing)
+			},
+			Expected: StatusFailing,
+			Code:     http.StatusServiceUnavailable,
+		},
+		{
+			Name: "Check times out",
+			Register: func(r *Registry) {
+				r.Register("slow", func(context.Context) error {
+					time.Sleep(time.Second)
+					return nil
+				}, Timeout(time.Millisecond))
+			},
+			Expected: StatusFailing,
+			Code:     http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			r := New()
+			tc.Register(r)
+
+			handler := r.NewMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+			assert.Equal(t, tc.Code, rec.Code)
+
+			var report Report
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Equal(t, tc.Expected, report.Status)
+		})
+	}
+}
+
+func Test_Liveness_OnlyLivenessChecks(t *testing.T) {
+	r := New()
+	r.Register("db", failing)
+	r.Register("deadlock", ok, Liveness())
+
+	report := r.Liveness(context.Background())
+	assert.Equal(t, StatusOK, report.Status)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "deadlock", report.Checks[0].Name)
+}
+
+func Test_CachedResults(t *testing.T) {
+	calls := 0
+	r := New()
+	r.Register("db", func(context.Context) error {
+		calls++
+		return nil
+	}, CacheTTL(time.Minute))
+
+	r.Readiness(context.Background())
+	r.Readiness(context.Background())
+	assert.Equal(t, 1, calls)
+}
+
+func Test_GRPCCheck(t *testing.T) {
+	r := New()
+	r.Register("db", ok)
+	r.Register("cache", failing, NonCritical())
+	s := &grpcServer{registry: r}
+	ctx := context.Background()
+
+	resp, err := s.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	resp, err = s.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "cache"})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	_, err = s.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "cow"})
+	assert.Error(t, err)
+}
+
+func Test_Drain(t *testing.T) {
+	r := New()
+	r.Register("db", ok)
+	assert.Equal(t, StatusOK, r.Readiness(context.Background()).Status)
+
+	r.Drain()
+	report := r.Readiness(context.Background())
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, http.StatusServiceUnavailable, report.ResponseCode())
+	assert.Equal(t, StatusOK, r.Liveness(context.Background()).Status)
+}
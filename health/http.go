@@ -0,0 +1,68 @@
+package health
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ConradKurth/gokit/responses"
+)
+
+const (
+	// LivenessPath is the path of the liveness probe
+	LivenessPath = "/livez"
+	// ReadinessPath is the path of the readiness probe
+	ReadinessPath = "/readyz"
+	// HeartbeatPath is the path of the heartbeat
+	HeartbeatPath = "/healthz"
+)
+
+// ResponseCode makes a failing report return a service unavailable status
+func (r Report) ResponseCode() int {
+	if r.Status == StatusFailing {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// NewMiddleware returns a middleware that serves the liveness and readiness probes.
+// Like the heartbeat middleware it should be added first, so probes skip all other middleware.
+func (r *Registry) NewMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodGet && req.Method != http.MethodHead {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			switch req.URL.Path {
+			case LivenessPath:
+				responses.Success(req.Context(), w, r.Liveness(req.Context()))
+			case ReadinessPath:
+				responses.Success(req.Context(), w, r.Readiness(req.Context()))
+			default:
+				next.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+// NewHeartbeat returns a middleware like chi's heartbeat that answers on the path without
+// running any checks. It fails with a service unavailable status while the registry is draining.
+func (r *Registry) NewHeartbeat(path string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if (req.Method != http.MethodGet && req.Method != http.MethodHead) || !strings.EqualFold(req.URL.Path, path) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			code := http.StatusOK
+			if r.IsDraining() {
+				code = http.StatusServiceUnavailable
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte("."))
+		})
+	}
+}
@@ -2,19 +2,68 @@ package instrument
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
-	"encoding/pem"
+	"errors"
 	"fmt"
 
 	"github.com/ConradKurth/gokit/config"
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/ConradKurth/gokit/temporalcodec"
+	"github.com/ConradKurth/gokit/temporalctx"
+	"github.com/ConradKurth/gokit/tlsconfig"
 	"go.opentelemetry.io/otel"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/contrib/opentelemetry"
+	"go.temporal.io/sdk/workflow"
 )
 
-// NewTemporalClient will create a new temporal client with interceptors added
-func NewTemporalClient(ctx context.Context, c *config.Config, serviceName string) (client.Client, error) {
+// namespaceHeader routes requests authenticated with an API key to the namespace
+const namespaceHeader = "temporal-namespace"
+
+type temporalOptions struct {
+	contextFields  []temporalctx.Field
+	logger         logger.Logger
+	metricsHandler client.MetricsHandler
+}
+
+// WithTemporalLogger sets the logger of the sdk, it defaults to a new gokit logger
+func WithTemporalLogger(l logger.Logger) func(*temporalOptions) {
+	return func(o *temporalOptions) {
+		o.logger = l
+	}
+}
+
+// WithMetricsHandler sets the handler of the sdk and interceptor metrics, it defaults to
+// the global OpenTelemetry meter provider
+func WithMetricsHandler(h client.MetricsHandler) func(*temporalOptions) {
+	return func(o *temporalOptions) {
+		o.metricsHandler = h
+	}
+}
+
+// WithContextFields propagates the fields to workflows and activities in addition to the
+// request values of the gokit middleware
+func WithContextFields(fields ...temporalctx.Field) func(*temporalOptions) {
+	return func(o *temporalOptions) {
+		o.contextFields = append(o.contextFields, fields...)
+	}
+}
+
+// NewTemporalClient will create a new temporal client with interceptors added. The user,
+// region, currency, admin flag and request id of the context are propagated to workflows
+// and activities.
+func NewTemporalClient(ctx context.Context, c *config.Config, serviceName string, opts ...func(*temporalOptions)) (client.Client, error) {
+	opt := temporalOptions{}
+	for _, o := range opts {
+		o(&opt)
+	}
+	if opt.logger == nil {
+		opt.logger = logger.New()
+	}
+	if opt.metricsHandler == nil {
+		opt.metricsHandler = opentelemetry.NewMetricsHandler(opentelemetry.MetricsHandlerOptions{
+			Meter: otel.Meter("temporal"),
+		})
+	}
 
 	_, err := GetTracingProvider(ctx, c, serviceName)
 	if err != nil {
@@ -26,44 +75,82 @@ func NewTemporalClient(ctx context.Context, c *config.Config, serviceName string
 		return nil, err
 	}
 
-	opts := client.Options{
-		HostPort:  c.GetString("temporal.hostPort"),
-		Namespace: c.GetString("temporal.namespace"),
+	clientOpts := client.Options{
+		HostPort:           c.GetString("temporal.hostPort"),
+		Namespace:          c.GetString("temporal.namespace"),
+		ContextPropagators: []workflow.ContextPropagator{temporalctx.NewContextPropagator(opt.contextFields...)},
+		Logger:             logger.NewLoggerAdapter(opt.logger),
+		MetricsHandler:     opt.metricsHandler,
+	}
+	if err := temporalConnection(c, &clientOpts); err != nil {
+		return nil, err
 	}
-	if c.GetBool("temporal.tls.enabled") {
-
-		pemBlock, _ := pem.Decode([]byte(c.GetString("temporal.tls.cert")))
 
-		parseCert, err := x509.ParseCertificate(pemBlock.Bytes)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing cert")
+	codecOpts, ok, err := temporalcodec.FromConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if clientOpts.DataConverter, err = temporalcodec.NewDataConverter(codecOpts); err != nil {
+			return nil, fmt.Errorf("creating data converter: %w", err)
 		}
+	}
+
+	clientOpts.Interceptors = append(clientOpts.Interceptors, tracingInterceptor)
+
+	temporalClient, err := client.Dial(clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial the client: %w", err)
+	}
+
+	return temporalClient, nil
+}
 
-		keyBlock, _ := pem.Decode([]byte(c.GetString("temporal.tls.key")))
-		key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+// temporalConnection sets the tls and auth options of the client. Temporal Cloud uses mTLS or
+// an API key, self-hosted clusters usually a private CA and custom headers.
+//
+// The certificate, key and CA are read from `temporal.tls` as files or values, see
+// tlsconfig.FromConfig. Files are reloaded when they change or the certificate is close to
+// its expiry. `temporal.apiKey` is sent as bearer token and `temporal.headers` are added to
+// every request.
+func temporalConnection(c *config.Config, opts *client.Options) error {
+	tlsOpts := tlsconfig.FromConfig(c, "temporal.tls")
+	apiKey := c.GetString("temporal.apiKey")
+	headers := c.GetStringMapString("temporal.headers")
+
+	if tlsOpts.Enabled {
+		tlsCfg, err := tlsconfig.NewClientConfig(tlsOpts)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing key")
+			return fmt.Errorf("temporal.tls: %w", err)
 		}
+		opts.ConnectionOptions.TLS = tlsCfg
+	}
 
-		tlsCert := tls.Certificate{
-			Certificate: [][]byte{parseCert.Raw},
-			PrivateKey:  key,
-			Leaf:        parseCert,
+	if apiKey != "" {
+		if !tlsOpts.Enabled {
+			return errors.New("temporal.apiKey requires temporal.tls.enabled")
 		}
-
-		opts.ConnectionOptions = client.ConnectionOptions{
-			TLS: &tls.Config{
-				Certificates: []tls.Certificate{tlsCert},
-			},
+		if opts.Namespace == "" {
+			return errors.New("temporal.apiKey requires temporal.namespace")
+		}
+		opts.Credentials = client.NewAPIKeyStaticCredentials(apiKey)
+		if _, ok := headers[namespaceHeader]; !ok {
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			headers[namespaceHeader] = opts.Namespace
 		}
 	}
 
-	opts.Interceptors = append(opts.Interceptors, tracingInterceptor)
-
-	temporalClient, err := client.Dial(opts)
-	if err != nil {
-		return nil, fmt.Errorf("could not dial the client: %w", err)
+	if len(headers) > 0 {
+		opts.HeadersProvider = staticHeaders(headers)
 	}
+	return nil
+}
 
-	return temporalClient, nil
+// staticHeaders adds the same headers to every request
+type staticHeaders map[string]string
+
+func (h staticHeaders) GetHeaders(_ context.Context) (map[string]string, error) {
+	return h, nil
 }
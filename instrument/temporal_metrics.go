@@ -0,0 +1,102 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Metrics recorded by the temporal metrics interceptor. They are tagged with the activity or
+// workflow type and the status: completed, failed or canceled.
+const (
+	MetricActivityDuration = "gokit_activity_duration"
+	MetricActivityAttempts = "gokit_activity_attempts"
+	MetricActivityRetries  = "gokit_activity_retries"
+	MetricWorkflowDuration = "gokit_workflow_duration"
+	MetricWorkflowResults  = "gokit_workflow_results"
+)
+
+// NewTemporalMetricsInterceptor returns a worker interceptor that records the duration and
+// attempts of activities and the duration of workflows. The metrics go to the metrics handler
+// of the client, workflows do not record while they are replayed.
+func NewTemporalMetricsInterceptor() interceptor.WorkerInterceptor {
+	return &metricsInterceptor{}
+}
+
+type metricsInterceptor struct {
+	interceptor.WorkerInterceptorBase
+}
+
+func (m *metricsInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	i := &activityMetrics{}
+	i.Next = next
+	return i
+}
+
+func (m *metricsInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	i := &workflowMetrics{}
+	i.Next = next
+	return i
+}
+
+type activityMetrics struct {
+	interceptor.ActivityInboundInterceptorBase
+}
+
+func (a *activityMetrics) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	start := time.Now()
+	result, err := a.Next.ExecuteActivity(ctx, in)
+	if errors.Is(err, activity.ErrResultPending) {
+		return result, err
+	}
+
+	info := activity.GetInfo(ctx)
+	handler := activity.GetMetricsHandler(ctx).WithTags(map[string]string{
+		"activity_type": info.ActivityType.Name,
+		"status":        temporalStatus(err),
+	})
+	handler.Timer(MetricActivityDuration).Record(time.Since(start))
+	handler.Counter(MetricActivityAttempts).Inc(1)
+	if info.Attempt > 1 {
+		handler.Counter(MetricActivityRetries).Inc(1)
+	}
+	return result, err
+}
+
+type workflowMetrics struct {
+	interceptor.WorkflowInboundInterceptorBase
+}
+
+func (w *workflowMetrics) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (interface{}, error) {
+	result, err := w.Next.ExecuteWorkflow(ctx, in)
+
+	info := workflow.GetInfo(ctx)
+	s := temporalStatus(err)
+	if workflow.IsContinueAsNewError(err) {
+		s = "continued_as_new"
+	}
+	// the handler of the workflow drops metrics during replay
+	handler := workflow.GetMetricsHandler(ctx).WithTags(map[string]string{
+		"workflow_type": info.WorkflowType.Name,
+		"status":        s,
+	})
+	handler.Timer(MetricWorkflowDuration).Record(workflow.Now(ctx).Sub(info.WorkflowStartTime))
+	handler.Counter(MetricWorkflowResults).Inc(1)
+	return result, err
+}
+
+func temporalStatus(err error) string {
+	switch {
+	case err == nil:
+		return "completed"
+	case temporal.IsCanceledError(err):
+		return "canceled"
+	default:
+		return "failed"
+	}
+}
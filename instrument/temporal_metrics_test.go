@@ -0,0 +1,148 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+type recordedMetric struct {
+	Name string
+	Tags map[string]string
+}
+
+// testMetrics records the gokit metrics with the tags of the interceptor
+type testMetrics struct {
+	mu       *sync.Mutex
+	tags     map[string]string
+	recorded *[]recordedMetric
+}
+
+func newTestMetrics() testMetrics {
+	return testMetrics{mu: &sync.Mutex{}, tags: map[string]string{}, recorded: &[]recordedMetric{}}
+}
+
+func (m testMetrics) WithTags(tags map[string]string) client.MetricsHandler {
+	merged := map[string]string{}
+	for k, v := range m.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return testMetrics{mu: m.mu, tags: merged, recorded: m.recorded}
+}
+
+func (m testMetrics) Counter(name string) client.MetricsCounter { return testMetric{m, name} }
+func (m testMetrics) Gauge(name string) client.MetricsGauge     { return testMetric{m, name} }
+func (m testMetrics) Timer(name string) client.MetricsTimer     { return testMetric{m, name} }
+
+func (m testMetrics) record(name string) {
+	if !strings.HasPrefix(name, "gokit_") {
+		return
+	}
+	tags := map[string]string{}
+	for _, k := range []string{"activity_type", "workflow_type", "status"} {
+		if v, ok := m.tags[k]; ok {
+			tags[k] = v
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*m.recorded = append(*m.recorded, recordedMetric{Name: name, Tags: tags})
+}
+
+type testMetric struct {
+	metrics testMetrics
+	name    string
+}
+
+func (m testMetric) Inc(int64)            { m.metrics.record(m.name) }
+func (m testMetric) Update(float64)       { m.metrics.record(m.name) }
+func (m testMetric) Record(time.Duration) { m.metrics.record(m.name) }
+
+func TestTemporalMetricsInterceptor(t *testing.T) {
+	// the sdk tags the handler of activities with the workflow type too
+	failed := map[string]string{"activity_type": "flaky", "workflow_type": "flakyWorkflow", "status": "failed"}
+	completed := map[string]string{"activity_type": "flaky", "workflow_type": "flakyWorkflow", "status": "completed"}
+
+	tt := []struct {
+		Name        string
+		WorkflowErr error
+		Expected    []recordedMetric
+	}{
+		{
+			Name: "completed",
+			Expected: []recordedMetric{
+				{Name: MetricActivityDuration, Tags: failed},
+				{Name: MetricActivityAttempts, Tags: failed},
+				{Name: MetricActivityDuration, Tags: completed},
+				{Name: MetricActivityAttempts, Tags: completed},
+				{Name: MetricActivityRetries, Tags: completed},
+				{Name: MetricWorkflowDuration, Tags: map[string]string{"workflow_type": "flakyWorkflow", "status": "completed"}},
+				{Name: MetricWorkflowResults, Tags: map[string]string{"workflow_type": "flakyWorkflow", "status": "completed"}},
+			},
+		},
+		{
+			Name:        "failed",
+			WorkflowErr: errors.New("invalid order"),
+			Expected: []recordedMetric{
+				{Name: MetricActivityDuration, Tags: failed},
+				{Name: MetricActivityAttempts, Tags: failed},
+				{Name: MetricActivityDuration, Tags: completed},
+				{Name: MetricActivityAttempts, Tags: completed},
+				{Name: MetricActivityRetries, Tags: completed},
+				{Name: MetricWorkflowDuration, Tags: map[string]string{"workflow_type": "flakyWorkflow", "status": "failed"}},
+				{Name: MetricWorkflowResults, Tags: map[string]string{"workflow_type": "flakyWorkflow", "status": "failed"}},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			attempts := 0
+			flaky := func(_ context.Context) error {
+				attempts++
+				if attempts < 2 {
+					return errors.New("flaky")
+				}
+				return nil
+			}
+			wf := func(ctx workflow.Context) error {
+				ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+					StartToCloseTimeout: time.Minute,
+					RetryPolicy:         &temporal.RetryPolicy{InitialInterval: time.Millisecond},
+				})
+				if err := workflow.ExecuteActivity(ctx, flaky).Get(ctx, nil); err != nil {
+					return err
+				}
+				return tc.WorkflowErr
+			}
+
+			metrics := newTestMetrics()
+			var s testsuite.WorkflowTestSuite
+			s.SetMetricsHandler(metrics)
+			env := s.NewTestWorkflowEnvironment()
+			env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{NewTemporalMetricsInterceptor()}})
+			env.RegisterWorkflowWithOptions(wf, workflow.RegisterOptions{Name: "flakyWorkflow"})
+			env.RegisterActivityWithOptions(flaky, activity.RegisterOptions{Name: "flaky"})
+
+			env.ExecuteWorkflow("flakyWorkflow")
+			require.True(t, env.IsWorkflowCompleted())
+			assert.Equal(t, tc.Expected, *metrics.recorded)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package instrument
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+)
+
+func Test_TemporalConnection(t *testing.T) {
+	tt := []struct {
+		Name    string
+		Config  map[string]interface{}
+		Headers map[string]string
+		TLS     bool
+		Err     string
+	}{
+		{
+			Name:   "plaintext",
+			Config: map[string]interface{}{},
+		},
+		{
+			Name: "api key",
+			Config: map[string]interface{}{
+				"namespace": "payments.a1b2c",
+				"apiKey":    "key",
+				"tls":       map[string]interface{}{"enabled": true},
+			},
+			Headers: map[string]string{"temporal-namespace": "payments.a1b2c"},
+			TLS:     true,
+		},
+		{
+			Name: "custom headers",
+			Config: map[string]interface{}{
+				"headers": map[string]interface{}{"X-Cluster-Token": "token"},
+			},
+			Headers: map[string]string{"x-cluster-token": "token"},
+		},
+		{
+			Name: "api key without tls",
+			Config: map[string]interface{}{
+				"namespace": "payments",
+				"apiKey":    "key",
+			},
+			Err: "temporal.apiKey requires temporal.tls.enabled",
+		},
+		{
+			Name: "certificate without key",
+			Config: map[string]interface{}{
+				"tls": map[string]interface{}{"enabled": true, "cert": "cert"},
+			},
+			Err: "temporal.tls: tls: certificate and key must be set together",
+		},
+		{
+			Name: "invalid CA",
+			Config: map[string]interface{}{
+				"tls": map[string]interface{}{"enabled": true, "ca": "not a pem"},
+			},
+			Err: "temporal.tls: tls: CA contains no certificates",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := config.LoadConfig(config.WithMap(map[string]interface{}{"temporal": tc.Config}))
+			opts := client.Options{Namespace: c.GetString("temporal.namespace")}
+
+			err := temporalConnection(c, &opts)
+			if tc.Err != "" {
+				assert.EqualError(t, err, tc.Err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.TLS, opts.ConnectionOptions.TLS != nil)
+			if tc.Headers == nil {
+				assert.Nil(t, opts.HeadersProvider)
+				return
+			}
+			headers, err := opts.HeadersProvider.GetHeaders(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tc.Headers, headers)
+		})
+	}
+}
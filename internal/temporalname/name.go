@@ -0,0 +1,14 @@
+// Package temporalname derives the names the temporal sdk registers functions with.
+package temporalname
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Func returns the name the sdk registers a function or method with by default
+func Func(fn interface{}) string {
+	full := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	return strings.TrimSuffix(full[strings.LastIndex(full, ".")+1:], "-fm")
+}
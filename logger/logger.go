@@ -61,6 +61,7 @@ type Logger interface {
 
 type logger struct {
 	logger *otelzap.Logger
+	level  *zap.AtomicLevel
 }
 
 type logtailWriter struct {
@@ -169,14 +170,16 @@ func NewV2(c *config.Config) Logger {
 		WS: writer,
 	}
 
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
 	if config.IsDevelopment() {
+		level.SetLevel(zap.DebugLevel)
 
 		writers := zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout), zapcore.AddSync(buffer))
 
 		core := zapcore.NewCore(
 			zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
 			writers,
-			zap.NewAtomicLevelAt(zap.DebugLevel),
+			level,
 		)
 
 		l, err = zap.NewDevelopment(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
@@ -187,7 +190,7 @@ func NewV2(c *config.Config) Logger {
 		core := zapcore.NewCore(
 			zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
 			writers,
-			zap.InfoLevel,
+			level,
 		)
 
 		l, err = zap.NewProduction(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
@@ -200,6 +203,7 @@ func NewV2(c *config.Config) Logger {
 
 	log := &logger{
 		logger: otelzap.New(l, otelzap.WithStackTrace(true)),
+		level:  &level,
 	}
 
 	// defer log.Close()
@@ -209,21 +213,31 @@ func NewV2(c *config.Config) Logger {
 
 // New creates a new logger based on the current environment config.
 func New() Logger {
-	var l *zap.Logger
-	var err error
+	cfg := zap.NewProductionConfig()
 	if config.IsDevelopment() {
-		l, err = zap.NewDevelopment()
-	} else {
-		l, err = zap.NewProduction()
+		cfg = zap.NewDevelopmentConfig()
 	}
 
+	l, err := cfg.Build()
 	if err != nil {
 		panic(err)
 	}
 
 	return &logger{
 		logger: otelzap.New(l, otelzap.WithStackTrace(true)),
+		level:  &cfg.Level,
+	}
+}
+
+// LevelHandler returns an http handler that reports the log level on GET and changes it
+// at runtime on PUT, e.g. with a body of {"level":"debug"}. It returns false if the
+// logger does not support changing its level.
+func LevelHandler(l Logger) (http.Handler, bool) {
+	log, ok := l.(*logger)
+	if !ok || log.level == nil {
+		return nil, false
 	}
+	return log.level, true
 }
 
 func (l *logger) Close() error {
@@ -8,15 +8,22 @@ import (
 	"go.uber.org/zap"
 )
 
+// LoggerAdapter makes a Logger usable as the logger of the temporal sdk
 type LoggerAdapter struct {
 	zl *otelzap.Logger
 }
 
+// NewLoggerAdapter returns an adapter for the logger. Loggers not created by this package,
+// e.g. the noop logger, log nothing.
 func NewLoggerAdapter(zapLogger Logger) *LoggerAdapter {
+	l, ok := zapLogger.(*logger)
+	if !ok {
+		return &LoggerAdapter{zl: otelzap.New(zap.NewNop())}
+	}
 	return &LoggerAdapter{
 		// Skip one call frame to exclude zap_adapter itself.
 		// Or it can be configured when logger is created (not always possible).
-		zl: zapLogger.(*logger).logger.WithOptions(zap.AddCallerSkip(1)),
+		zl: l.logger.WithOptions(zap.AddCallerSkip(1)),
 	}
 }
 
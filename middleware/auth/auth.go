@@ -29,7 +29,30 @@ func NewMiddleware(c *config.Config) func(http.Handler) http.Handler {
 }
 
 func auth(c *config.Config, createVerify createVerifyFunc) func(http.Handler) http.Handler {
-	enabled := c.GetBoolDefault("auth.enabled", true)
+	a := newAuthenticator(c, createVerify)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, ok := a.authenticate(r.Context(), r.Header.Get(adminHTTPHeader), r.Header.Get("authorization"))
+			if !ok {
+				responses.Empty(w, http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticator validates the admin key or the bearer token of a request, it is shared
+// by the http middleware and the grpc interceptors.
+type authenticator struct {
+	enabled   bool
+	verifiers map[string]tokenVerify
+	admins    map[string]struct{}
+	parser    jwt.Parser
+}
+
+func newAuthenticator(c *config.Config, createVerify createVerifyFunc) *authenticator {
 	cids := c.GetStringSlice("auth.cids")
 
 	verifiers := map[string]tokenVerify{}
@@ -46,71 +69,66 @@ func auth(c *config.Config, createVerify createVerifyFunc) func(http.Handler) ht
 	for _, a := range adminSecrets {
 		mapping[a] = struct{}{}
 	}
-	parser := jwt.Parser{}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !enabled {
-				next.ServeHTTP(w, r)
-				return
-			}
+	return &authenticator{
+		enabled:   c.GetBoolDefault("auth.enabled", true),
+		verifiers: verifiers,
+		admins:    mapping,
+	}
+}
 
-			adminHeader := r.Header.Get(adminHTTPHeader)
-			_, ok := mapping[adminHeader]
-			if ok && adminHeader != "" {
-				// let's inject that we are an admin
-				ctx := SetAdmin(r.Context())
-				next.ServeHTTP(w, r.WithContext(ctx))
-				return
-			}
+// authenticate returns the context to continue with and whether the caller is authenticated
+func (a *authenticator) authenticate(ctx context.Context, adminHeader, authorization string) (context.Context, bool) {
+	if !a.enabled {
+		return ctx, true
+	}
 
-			splitToken := strings.Split(r.Header.Get("authorization"), "Bearer")
-			if len(splitToken) != 2 {
-				logger.GetLoggerReq(r).WarnCtx(r.Context(),
-					"Token not of size two",
-					logger.Any("size", len(splitToken)))
-				responses.Empty(w, http.StatusUnauthorized)
-				return
-			}
+	_, ok := a.admins[adminHeader]
+	if ok && adminHeader != "" {
+		// let's inject that we are an admin
+		return SetAdmin(ctx), true
+	}
 
-			authToken := strings.TrimSpace(splitToken[1])
+	splitToken := strings.Split(authorization, "Bearer")
+	if len(splitToken) != 2 {
+		logger.GetLogger(ctx).WarnCtx(ctx,
+			"Token not of size two",
+			logger.Any("size", len(splitToken)))
+		return ctx, false
+	}
 
-			claims := jwt.MapClaims{}
-			_, _, err := parser.ParseUnverified(authToken, claims)
-			if err != nil {
-				logger.GetLoggerReq(r).WarnCtx(r.Context(),
-					"Error parsing claims",
-					logger.ErrField(err))
-				responses.Empty(w, http.StatusUnauthorized)
-				return
-			}
+	authToken := strings.TrimSpace(splitToken[1])
 
-			cid, ok := claims["cid"].(string)
-			if !ok {
-				logger.GetLoggerReq(r).WarnCtx(r.Context(),
-					"no audience in the claims",
-					logger.ErrField(err))
-				responses.Empty(w, http.StatusUnauthorized)
-				return
-			}
+	claims := jwt.MapClaims{}
+	_, _, err := a.parser.ParseUnverified(authToken, claims)
+	if err != nil {
+		logger.GetLogger(ctx).WarnCtx(ctx,
+			"Error parsing claims",
+			logger.ErrField(err))
+		return ctx, false
+	}
 
-			v, ok := verifiers[cid]
-			if !ok {
-				logger.GetLoggerReq(r).WarnCtx(r.Context(),
-					"Did not have verifier for audience",
-					logger.ErrField(err),
-					logger.Any("cid", cid))
-				responses.Empty(w, http.StatusUnauthorized)
-				return
-			}
+	cid, ok := claims["cid"].(string)
+	if !ok {
+		logger.GetLogger(ctx).WarnCtx(ctx,
+			"no audience in the claims",
+			logger.ErrField(err))
+		return ctx, false
+	}
 
-			if _, err := v.VerifyAccessToken(authToken); err != nil {
-				responses.Empty(w, http.StatusUnauthorized)
-				return
-			}
-			next.ServeHTTP(w, r)
-		})
+	v, ok := a.verifiers[cid]
+	if !ok {
+		logger.GetLogger(ctx).WarnCtx(ctx,
+			"Did not have verifier for audience",
+			logger.ErrField(err),
+			logger.Any("cid", cid))
+		return ctx, false
+	}
+
+	if _, err := v.VerifyAccessToken(authToken); err != nil {
+		return ctx, false
 	}
+	return ctx, true
 }
 
 // IsAdmin will return if the is admin token is set
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/ConradKurth/gokit/middleware/grpcutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns the grpc counterpart of the auth middleware. The token and
+// admin key are read from the authorization and x-admin-key metadata.
+func UnaryServerInterceptor(c *config.Config) grpc.UnaryServerInterceptor {
+	return grpcutil.UnaryServerInterceptor(fromMetadata(newAuthenticator(c, newVerifier)))
+}
+
+// StreamServerInterceptor returns the grpc counterpart of the auth middleware. The token and
+// admin key are read from the authorization and x-admin-key metadata.
+func StreamServerInterceptor(c *config.Config) grpc.StreamServerInterceptor {
+	return grpcutil.StreamServerInterceptor(fromMetadata(newAuthenticator(c, newVerifier)))
+}
+
+func fromMetadata(a *authenticator) grpcutil.ContextFunc {
+	return func(ctx context.Context) (context.Context, error) {
+		ctx, ok := a.authenticate(ctx,
+			grpcutil.Metadata(ctx, adminHTTPHeader),
+			grpcutil.Metadata(ctx, "authorization"))
+		if !ok {
+			return ctx, status.Error(codes.Unauthenticated, "unauthenticated")
+		}
+		return ctx, nil
+	}
+}
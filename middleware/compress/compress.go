@@ -0,0 +1,278 @@
+package compress
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// Brotli is the br content encoding
+	Brotli = "br"
+	// Gzip is the gzip content encoding
+	Gzip = "gzip"
+	// Deflate is the deflate content encoding
+	Deflate = "deflate"
+
+	defaultLevel = 4
+)
+
+var defaultContentTypes = []string{
+	"text/html",
+	"text/css",
+	"text/plain",
+	"text/csv",
+	"text/javascript",
+	"application/javascript",
+	"application/x-javascript",
+	"application/json",
+	"application/atom+xml",
+	"application/rss+xml",
+	"image/svg+xml",
+}
+
+// Options configure the compression middleware
+type Options struct {
+	// Level is the compression level passed to every encoder
+	Level int
+	// Algorithms are the supported encodings in order of preference
+	Algorithms []string
+	// MinSize is the response size in bytes below which responses are sent uncompressed
+	MinSize int
+	// ContentTypes are the content types that get compressed
+	ContentTypes []string
+}
+
+type encoderFunc func(w io.Writer, level int) io.WriteCloser
+
+var encoders = map[string]encoderFunc{
+	Brotli: func(w io.Writer, level int) io.WriteCloser {
+		return brotli.NewWriterLevel(w, level)
+	},
+	Gzip: func(w io.Writer, level int) io.WriteCloser {
+		gw, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			gw = gzip.NewWriter(w)
+		}
+		return gw
+	},
+	Deflate: func(w io.Writer, level int) io.WriteCloser {
+		fw, err := flate.NewWriter(w, level)
+		if err != nil {
+			fw, _ = flate.NewWriter(w, flate.DefaultCompression)
+		}
+		return fw
+	},
+}
+
+// NewMiddleware returns a new compression middleware. It negotiates the encoding from
+// the Accept-Encoding header and only compresses responses of at least MinSize bytes.
+func NewMiddleware(o Options) (func(http.Handler) http.Handler, error) {
+	if o.Level == 0 {
+		o.Level = defaultLevel
+	}
+	if len(o.Algorithms) == 0 {
+		o.Algorithms = []string{Brotli, Gzip, Deflate}
+	}
+	if len(o.ContentTypes) == 0 {
+		o.ContentTypes = defaultContentTypes
+	}
+	for _, a := range o.Algorithms {
+		if _, ok := encoders[a]; !ok {
+			return nil, errors.New("unsupported compression algorithm: " + a)
+		}
+	}
+
+	contentTypes := make(map[string]struct{}, len(o.ContentTypes))
+	for _, t := range o.ContentTypes {
+		contentTypes[t] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := negotiate(r.Header.Get("Accept-Encoding"), o.Algorithms)
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				level:          o.Level,
+				minSize:        o.MinSize,
+				contentTypes:   contentTypes,
+			}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}, nil
+}
+
+// negotiate returns the first supported algorithm the client accepts
+func negotiate(header string, algorithms []string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		ok := true
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if q, found := strings.CutPrefix(f, "q="); found {
+				if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+					ok = false
+				}
+			}
+		}
+		accepted[name] = ok
+	}
+
+	for _, a := range algorithms {
+		if ok, found := accepted[a]; found && ok {
+			return a
+		}
+		if ok, found := accepted["*"]; found && ok {
+			if _, explicit := accepted[a]; !explicit {
+				return a
+			}
+		}
+	}
+	return ""
+}
+
+// compressWriter buffers the response until MinSize bytes are written and then
+// decides whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+
+	encoding     string
+	level        int
+	minSize      int
+	contentTypes map[string]struct{}
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+	cw.status = code
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.minSize {
+			return len(p), nil
+		}
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// decide writes the header and the buffered body, compressed if possible
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	h := cw.Header()
+	if compress && cw.compressible() {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Add("Vary", "Accept-Encoding")
+		h.Del("Content-Length")
+		cw.encoder = encoders[cw.encoding](cw.ResponseWriter, cw.level)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.encoder != nil {
+		_, err := cw.encoder.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+func (cw *compressWriter) compressible() bool {
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = contentType[:idx]
+	}
+	_, ok := cw.contentTypes[strings.TrimSpace(contentType)]
+	return ok
+}
+
+// Flush sends everything buffered so far. Streamed responses are compressed if possible.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if err := cw.decide(true); err != nil {
+			return
+		}
+	}
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets websockets take over the connection
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		cw.decided = true
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("http.Hijacker is unavailable on the writer")
+}
+
+// Close writes small responses uncompressed and finishes the encoder
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return nil
+		}
+		if err := cw.decide(false); err != nil {
+			return err
+		}
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	return nil
+}
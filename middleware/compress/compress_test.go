@@ -0,0 +1,101 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressMiddleware(t *testing.T) {
+	large := strings.Repeat("cow", 100)
+
+	tt := []struct {
+		Name           string
+		Options        Options
+		AcceptEncoding string
+		ContentType    string
+		Body           string
+		Encoding       string
+	}{
+		{
+			Name:           "Brotli preferred",
+			AcceptEncoding: "gzip, br",
+			ContentType:    "application/json",
+			Body:           large,
+			Encoding:       Brotli,
+		},
+		{
+			Name:           "Only configured algorithms",
+			Options:        Options{Algorithms: []string{Gzip}},
+			AcceptEncoding: "gzip, br",
+			ContentType:    "application/json",
+			Body:           large,
+			Encoding:       Gzip,
+		},
+		{
+			Name:           "Small responses are not compressed",
+			Options:        Options{MinSize: 1024},
+			AcceptEncoding: "br",
+			ContentType:    "application/json",
+			Body:           large,
+		},
+		{
+			Name:           "Unsupported content type",
+			AcceptEncoding: "br",
+			ContentType:    "image/png",
+			Body:           large,
+		},
+		{
+			Name:           "Client does not accept any encoding",
+			AcceptEncoding: "br;q=0",
+			ContentType:    "application/json",
+			Body:           large,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			m, err := NewMiddleware(tc.Options)
+			require.NoError(t, err)
+
+			h := m(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tc.ContentType)
+				w.WriteHeader(http.StatusCreated)
+				_, err := w.Write([]byte(tc.Body))
+				require.NoError(t, err)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tc.AcceptEncoding)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, tc.Encoding, rec.Header().Get("Content-Encoding"))
+
+			var body io.Reader = rec.Body
+			switch tc.Encoding {
+			case Brotli:
+				body = brotli.NewReader(rec.Body)
+			case Gzip:
+				body, err = gzip.NewReader(rec.Body)
+				require.NoError(t, err)
+			}
+			b, err := io.ReadAll(body)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Body, string(b))
+		})
+	}
+}
+
+func TestCompressMiddleware_InvalidAlgorithm(t *testing.T) {
+	_, err := NewMiddleware(Options{Algorithms: []string{"cow"}})
+	assert.Error(t, err)
+}
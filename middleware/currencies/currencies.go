@@ -42,27 +42,8 @@ var (
 func NewMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			header := r.Header.Get(CurrencyHTTPHeader)
-			if header == "" {
-				header = DefaultCurrency.String()
-			}
-
-			cur, err := currency.ParseISO(header)
-			if err != nil {
-				logger.GetLogger(r.Context()).ErrorCtx(r.Context(),
-					"unable to parse currency",
-					logger.ErrField(err),
-					logger.Any("currency", header))
-				responses.Empty(w, http.StatusBadRequest)
-				return
-			}
-
-			ctx, err := AddToCtx(r.Context(), cur)
+			ctx, err := loadCurrency(r.Context(), r.Header.Get(CurrencyHTTPHeader))
 			if err != nil {
-				logger.GetLogger(r.Context()).ErrorCtx(r.Context(),
-					"unable to add currency",
-					logger.ErrField(err),
-					logger.Any("currency", header))
 				responses.Empty(w, http.StatusBadRequest)
 				return
 			}
@@ -72,6 +53,32 @@ func NewMiddleware() func(http.Handler) http.Handler {
 	}
 }
 
+// loadCurrency parses the currency, falling back to the default one, and adds it to the context.
+func loadCurrency(ctx context.Context, header string) (context.Context, error) {
+	if header == "" {
+		header = DefaultCurrency.String()
+	}
+
+	cur, err := currency.ParseISO(header)
+	if err != nil {
+		logger.GetLogger(ctx).ErrorCtx(ctx,
+			"unable to parse currency",
+			logger.ErrField(err),
+			logger.Any("currency", header))
+		return ctx, err
+	}
+
+	ctx, err = AddToCtx(ctx, cur)
+	if err != nil {
+		logger.GetLogger(ctx).ErrorCtx(ctx,
+			"unable to add currency",
+			logger.ErrField(err),
+			logger.Any("currency", header))
+		return ctx, err
+	}
+	return ctx, nil
+}
+
 // GetCurrency will get the users local currency.
 func GetCurrency(ctx context.Context) (currency.Unit, error) {
 	v, ok := ctx.Value(locationKey).(currency.Unit)
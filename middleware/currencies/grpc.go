@@ -0,0 +1,30 @@
+package currencies
+
+import (
+	"context"
+
+	"github.com/ConradKurth/gokit/middleware/grpcutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func fromMetadata(ctx context.Context) (context.Context, error) {
+	ctx, err := loadCurrency(ctx, grpcutil.Metadata(ctx, CurrencyHTTPHeader))
+	if err != nil {
+		return ctx, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return ctx, nil
+}
+
+// UnaryServerInterceptor returns the grpc counterpart of the currency middleware. It reads
+// the currency from the x-currency metadata.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return grpcutil.UnaryServerInterceptor(fromMetadata)
+}
+
+// StreamServerInterceptor returns the grpc counterpart of the currency middleware. It reads
+// the currency from the x-currency metadata.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return grpcutil.StreamServerInterceptor(fromMetadata)
+}
@@ -0,0 +1,56 @@
+package currencies_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ConradKurth/gokit/middleware/currencies"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/currency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCurrenciesUnaryServerInterceptor(t *testing.T) {
+	tt := []struct {
+		Name         string
+		Expected     currency.Unit
+		MD           metadata.MD
+		ExpectedCode codes.Code
+	}{
+		{
+			Name:     "Default to USD when no metadata set",
+			Expected: currencies.DefaultCurrency,
+		},
+		{
+			Name:     "HKD from the currency metadata",
+			Expected: currency.HKD,
+			MD:       metadata.Pairs("x-currency", "HKD"),
+		},
+		{
+			Name:         "Invalid currency",
+			MD:           metadata.Pairs("x-currency", "nope"),
+			ExpectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tc.MD)
+
+			called := false
+			_, err := currencies.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test/Do"},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					called = true
+					c, err := currencies.GetCurrency(ctx)
+					assert.NoError(t, err)
+					assert.Equal(t, tc.Expected, c)
+					return nil, nil
+				})
+			assert.Equal(t, tc.ExpectedCode, status.Code(err))
+			assert.Equal(t, tc.ExpectedCode == codes.OK, called)
+		})
+	}
+}
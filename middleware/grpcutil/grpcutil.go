@@ -0,0 +1,59 @@
+package grpcutil
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ContextFunc enriches the context of an incoming call. Returning an error rejects the call,
+// it should be a grpc status error.
+type ContextFunc func(ctx context.Context) (context.Context, error)
+
+// UnaryServerInterceptor turns a context func into a unary interceptor
+func UnaryServerInterceptor(fn ContextFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor turns a context func into a stream interceptor
+func StreamServerInterceptor(fn ContextFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := fn(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, WrapServerStream(ss, ctx))
+	}
+}
+
+// WrapServerStream returns a stream that uses the passed context
+func WrapServerStream(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &serverStream{ServerStream: ss, ctx: ctx}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// Metadata returns the first incoming metadata value for the key. Keys are case insensitive
+// like http headers, so the http header names can be used.
+func Metadata(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, strings.ToLower(key))
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
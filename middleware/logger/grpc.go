@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/ConradKurth/gokit/middleware/grpcutil"
+	"github.com/ConradKurth/gokit/middleware/userinfo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns the grpc counterpart of the logger middleware. It sets the
+// logger on the context and logs the result of the call.
+func UnaryServerInterceptor(log Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx = logger.SetLogger(ctx, log)
+
+		resp, err := handler(ctx, req)
+		logCall(ctx, log, info.FullMethod, "unary", start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns the grpc counterpart of the logger middleware. It sets the
+// logger on the context and logs the result of the stream once it is done.
+func StreamServerInterceptor(log Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := logger.SetLogger(ss.Context(), log)
+
+		err := handler(srv, grpcutil.WrapServerStream(ss, ctx))
+		logCall(ctx, log, info.FullMethod, "stream", start, err)
+		return err
+	}
+}
+
+func logCall(ctx context.Context, log Logger, method, kind string, start time.Time, err error) {
+	userId, uErr := userinfo.GetUserID(ctx)
+	if uErr != nil {
+		log.DebugCtx(ctx, "No userID found")
+	}
+
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+	}
+
+	code := status.Code(err)
+	fields := []logger.Field{
+		logger.Any("type", "request"),
+		logger.Any("grpc_method", method),
+		logger.Any("grpc_type", kind),
+		logger.Any("code", code.String()),
+		logger.Any("ip", ip),
+		logger.Any("duration_ms", time.Since(start).Milliseconds()),
+		logger.Any("user_agent", grpcutil.Metadata(ctx, "user-agent")),
+		logger.Any("user_id", userId),
+	}
+	if err != nil {
+		fields = append(fields, logger.ErrField(err))
+	}
+
+	switch code {
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.Unauthenticated, codes.FailedPrecondition, codes.OutOfRange, codes.Canceled,
+		codes.ResourceExhausted, codes.Aborted:
+		// client error
+		log.WarnCtx(ctx, "", fields...)
+	case codes.OK:
+		log.InfoCtx(ctx, "", fields...)
+	default:
+		// server error
+		log.ErrorCtx(ctx, "", fields...)
+	}
+}
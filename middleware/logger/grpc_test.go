@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoggerUnaryServerInterceptor(t *testing.T) {
+	tt := []struct {
+		Name  string
+		Err   error
+		Level zapcore.Level
+	}{
+		{
+			Name:  "ok is logged on info level",
+			Level: zap.InfoLevel,
+		},
+		{
+			Name:  "client errors are logged on warning level",
+			Err:   status.Error(codes.NotFound, "not found"),
+			Level: zap.WarnLevel,
+		},
+		{
+			Name:  "server errors are logged on error level",
+			Err:   status.Error(codes.Internal, "boom"),
+			Level: zap.ErrorLevel,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			log := newTestLogger()
+
+			_, err := UnaryServerInterceptor(log)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Do"},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					assert.Equal(t, log, logger.GetLogger(ctx))
+					return nil, tc.Err
+				})
+			assert.Equal(t, tc.Err, err)
+
+			logs := log.observed.TakeAll()
+			require.Equal(t, 2, len(logs))
+			assert.Equal(t, zap.DebugLevel, logs[0].Entry.Level) // no userid found
+			assert.Equal(t, tc.Level, logs[1].Entry.Level)
+			assert.Equal(t, "/test/Do", logs[1].ContextMap()["grpc_method"])
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ConradKurth/gokit/logger"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/workflow"
+)
+
+// NewTemporalInterceptor returns the worker counterpart of the logger middleware. It sets the
+// logger on the context of activities and logs when activities and workflows start, finish,
+// fail and are retried. Workflows are not logged while they are replayed.
+func NewTemporalInterceptor(log Logger) interceptor.WorkerInterceptor {
+	return &workerInterceptor{log: log}
+}
+
+type workerInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	log Logger
+}
+
+func (w *workerInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	i := &activityInterceptor{log: w.log}
+	i.Next = next
+	return i
+}
+
+func (w *workerInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	i := &workflowInterceptor{log: w.log}
+	i.Next = next
+	return i
+}
+
+type activityInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+	log Logger
+}
+
+func (a *activityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	start := time.Now()
+	ctx = logger.SetLogger(ctx, a.log)
+
+	info := activity.GetInfo(ctx)
+	fields := []logger.Field{
+		logger.Any("type", "activity"),
+		logger.Any("activity_type", info.ActivityType.Name),
+		logger.Any("activity_id", info.ActivityID),
+		logger.Any("workflow_type", info.WorkflowType.Name),
+		logger.Any("workflow_id", info.WorkflowExecution.ID),
+		logger.Any("run_id", info.WorkflowExecution.RunID),
+		logger.Any("task_queue", info.TaskQueue),
+		logger.Any("attempt", info.Attempt),
+	}
+	if info.Attempt > 1 {
+		a.log.InfoCtx(ctx, "Retrying activity", fields...)
+	} else {
+		a.log.DebugCtx(ctx, "Starting activity", fields...)
+	}
+
+	result, err := a.Next.ExecuteActivity(ctx, in)
+
+	fields = append(fields, logger.Any("duration_ms", time.Since(start).Milliseconds()))
+	if errors.Is(err, activity.ErrResultPending) {
+		a.log.InfoCtx(ctx, "Activity will complete asynchronously", fields...)
+		return result, err
+	}
+	if err != nil {
+		// failures are reported by the sentry interceptor once they are final
+		a.log.WarnCtx(ctx, "Activity failed", append(fields, logger.ErrField(err))...)
+		return result, err
+	}
+	a.log.InfoCtx(ctx, "Activity completed", fields...)
+	return result, nil
+}
+
+type workflowInterceptor struct {
+	interceptor.WorkflowInboundInterceptorBase
+	log Logger
+}
+
+func (w *workflowInterceptor) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (interface{}, error) {
+	info := workflow.GetInfo(ctx)
+	fields := []logger.Field{
+		logger.Any("type", "workflow"),
+		logger.Any("workflow_type", info.WorkflowType.Name),
+		logger.Any("workflow_id", info.WorkflowExecution.ID),
+		logger.Any("run_id", info.WorkflowExecution.RunID),
+		logger.Any("task_queue", info.TaskQueueName),
+		logger.Any("attempt", info.Attempt),
+	}
+	// the logger is not replay safe, nothing is logged for the history that already happened
+	log := func(fn func(context.Context, string, ...logger.Field), msg string, fields ...logger.Field) {
+		if !workflow.IsReplaying(ctx) {
+			fn(context.Background(), msg, fields...)
+		}
+	}
+
+	log(w.log.DebugCtx, "Starting workflow", fields...)
+
+	result, err := w.Next.ExecuteWorkflow(ctx, in)
+
+	fields = append(fields, logger.Any("duration_ms", workflow.Now(ctx).Sub(info.WorkflowStartTime).Milliseconds()))
+	if workflow.IsContinueAsNewError(err) {
+		log(w.log.InfoCtx, "Workflow continued as new", fields...)
+		return result, err
+	}
+	if err != nil {
+		log(w.log.WarnCtx, "Workflow failed", append(fields, logger.ErrField(err))...)
+		return result, err
+	}
+	log(w.log.InfoCtx, "Workflow completed", fields...)
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+func TestTemporalInterceptor(t *testing.T) {
+	log := newTestLogger()
+
+	attempts := 0
+	flaky := func(_ context.Context) error {
+		attempts++
+		if attempts < 2 {
+			return errors.New("flaky")
+		}
+		return nil
+	}
+	wf := func(ctx workflow.Context) error {
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			StartToCloseTimeout: time.Minute,
+			RetryPolicy:         &temporal.RetryPolicy{InitialInterval: time.Millisecond},
+		})
+		return workflow.ExecuteActivity(ctx, flaky).Get(ctx, nil)
+	}
+
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{NewTemporalInterceptor(log)}})
+	env.RegisterWorkflowWithOptions(wf, workflow.RegisterOptions{Name: "flakyWorkflow"})
+	env.RegisterActivityWithOptions(flaky, activity.RegisterOptions{Name: "flaky"})
+
+	env.ExecuteWorkflow("flakyWorkflow")
+	require.NoError(t, env.GetWorkflowError())
+
+	var messages []string
+	for _, l := range log.observed.All() {
+		if l.Level != zap.DebugLevel {
+			messages = append(messages, l.Message)
+		}
+	}
+	assert.Equal(t, []string{"Activity failed", "Retrying activity", "Activity completed", "Workflow completed"}, messages)
+
+	failed := log.observed.FilterMessage("Activity failed").All()[0].ContextMap()
+	assert.Equal(t, "flaky", failed["activity_type"])
+	assert.Equal(t, "flakyWorkflow", failed["workflow_type"])
+	assert.NotEmpty(t, failed["run_id"])
+	assert.Equal(t, "flaky", failed["error"])
+}
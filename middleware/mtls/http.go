@@ -0,0 +1,40 @@
+package mtls
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/ConradKurth/gokit/responses"
+)
+
+// NewMiddleware requires a client certificate verified by the tls config of the server and
+// adds its identity to the context. With allowed names only peers with one of them as common
+// name, dns or uri SAN pass.
+func NewMiddleware(allowed ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				responses.Empty(w, http.StatusUnauthorized)
+				return
+			}
+			id := NewIdentity(r.TLS.VerifiedChains[0][0])
+			if len(allowed) > 0 && !id.matches(allowed) {
+				responses.Empty(w, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(SetPeerIdentity(r.Context(), id)))
+		})
+	}
+}
+
+func (id Identity) matches(names []string) bool {
+	if slices.Contains(names, id.CommonName) {
+		return true
+	}
+	for _, n := range append(slices.Clone(id.DNSNames), id.URIs...) {
+		if slices.Contains(names, n) {
+			return true
+		}
+	}
+	return false
+}
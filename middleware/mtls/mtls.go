@@ -0,0 +1,80 @@
+package mtls
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+
+	"github.com/ConradKurth/gokit/middleware/grpcutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "mtls-key-" + string(c)
+}
+
+const identityKey = contextKey("identity")
+
+// Identity is the identity of a peer from its verified client certificate
+type Identity struct {
+	CommonName   string
+	Organization []string
+	DNSNames     []string
+	// URIs holds the uri SANs, e.g. SPIFFE ids
+	URIs []string
+}
+
+// NewIdentity returns the identity of a certificate
+func NewIdentity(cert *x509.Certificate) Identity {
+	id := Identity{
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+		DNSNames:     cert.DNSNames,
+	}
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+	return id
+}
+
+// SetPeerIdentity will set the peer identity on the context
+func SetPeerIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey, id)
+}
+
+// GetPeerIdentity finds the identity of the peer. REQUIRES the mtls interceptor to have run.
+func GetPeerIdentity(ctx context.Context) (Identity, error) {
+	val, ok := ctx.Value(identityKey).(Identity)
+	if !ok {
+		return Identity{}, errors.New("no peer identity was set")
+	}
+	return val, nil
+}
+
+func fromPeer(ctx context.Context) (context.Context, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx, status.Error(codes.Unauthenticated, "no peer")
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return ctx, status.Error(codes.Unauthenticated, "no verified client certificate")
+	}
+	return SetPeerIdentity(ctx, NewIdentity(info.State.VerifiedChains[0][0])), nil
+}
+
+// UnaryServerInterceptor adds the identity of the verified client certificate to the context
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return grpcutil.UnaryServerInterceptor(fromPeer)
+}
+
+// StreamServerInterceptor adds the identity of the verified client certificate to the context
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return grpcutil.StreamServerInterceptor(fromPeer)
+}
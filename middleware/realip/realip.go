@@ -0,0 +1,87 @@
+package realip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	trueClientIP  = http.CanonicalHeaderKey("True-Client-IP")
+	xForwardedFor = http.CanonicalHeaderKey("X-Forwarded-For")
+	xRealIP       = http.CanonicalHeaderKey("X-Real-IP")
+)
+
+// NewMiddleware returns a middleware that sets the request's RemoteAddr from the
+// True-Client-IP, X-Real-IP or X-Forwarded-For headers, but only if the request came
+// through one of the trusted proxies. Proxies are given as CIDRs or single IPs. If no
+// proxies are passed, every peer is trusted.
+func NewMiddleware(trustedProxies []string) (func(http.Handler) http.Handler, error) {
+	nets := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, p := range trustedProxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("parsing trusted proxy '%s': %w", p, err)
+		}
+		nets = append(nets, n)
+	}
+
+	trusted := func(ip net.IP) bool {
+		if len(nets) == 0 {
+			return true
+		}
+		for _, n := range nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rip := realIP(r, trusted); rip != "" {
+				r.RemoteAddr = rip
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func realIP(r *http.Request, trusted func(net.IP) bool) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer := net.ParseIP(host)
+	if peer == nil || !trusted(peer) {
+		return ""
+	}
+
+	for _, h := range []string{trueClientIP, xRealIP} {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(h))); ip != nil {
+			return ip.String()
+		}
+	}
+
+	// walk the forwarded chain from the closest hop and return the first untrusted address
+	hops := strings.Split(r.Header.Get(xForwardedFor), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			return ""
+		}
+		if i == 0 || !trusted(ip) {
+			return ip.String()
+		}
+	}
+	return ""
+}
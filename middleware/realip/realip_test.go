@@ -0,0 +1,72 @@
+package realip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealIPMiddleware(t *testing.T) {
+	tt := []struct {
+		Name       string
+		Trusted    []string
+		RemoteAddr string
+		Headers    map[string]string
+		Expected   string
+	}{
+		{
+			Name:       "Trust every peer without proxies",
+			RemoteAddr: "10.0.0.1:1234",
+			Headers:    map[string]string{"X-Real-IP": "1.2.3.4"},
+			Expected:   "1.2.3.4",
+		},
+		{
+			Name:       "Untrusted peer keeps its address",
+			Trusted:    []string{"10.0.0.0/8"},
+			RemoteAddr: "8.8.8.8:1234",
+			Headers:    map[string]string{"X-Real-IP": "1.2.3.4"},
+			Expected:   "8.8.8.8:1234",
+		},
+		{
+			Name:       "Trusted peer with forwarded chain",
+			Trusted:    []string{"10.0.0.0/8", "192.168.1.1"},
+			RemoteAddr: "10.0.0.1:1234",
+			Headers:    map[string]string{"X-Forwarded-For": "6.6.6.6, 1.2.3.4, 192.168.1.1"},
+			Expected:   "1.2.3.4",
+		},
+		{
+			Name:       "Trusted peer without headers",
+			Trusted:    []string{"10.0.0.0/8"},
+			RemoteAddr: "10.0.0.1:1234",
+			Expected:   "10.0.0.1:1234",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			m, err := NewMiddleware(tc.Trusted)
+			require.NoError(t, err)
+
+			var got string
+			h := m(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.RemoteAddr
+			for k, v := range tc.Headers {
+				req.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tc.Expected, got)
+		})
+	}
+}
+
+func TestRealIPMiddleware_InvalidProxy(t *testing.T) {
+	_, err := NewMiddleware([]string{"cow"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/ConradKurth/gokit/responses"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// PanicError is the error a recovered panic is converted into
+// nolint: errname
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error will format the error
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap returns the panic value if it was an error
+func (p *PanicError) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// Recovered converts a recovered panic into a PanicError, logs it with its stack and
+// reports it to sentry through the logger. The fields describe the request or workflow
+// the panic happened in. The logger on the context is preferred over the passed one.
+func Recovered(ctx context.Context, log logger.Logger, r interface{}, fields ...logger.Field) *PanicError {
+	p := &PanicError{
+		Value: r,
+		Stack: debug.Stack(),
+	}
+
+	if l, ok := ctx.Value(logger.GetContextKey()).(logger.Logger); ok {
+		log = l
+	}
+	fields = append(fields,
+		logger.ErrField(p),
+		logger.Any("stack", string(p.Stack)))
+	log.ErrorCtx(ctx, "Recovered from panic", fields...)
+
+	return p
+}
+
+// NewMiddleware returns a middleware that recovers panics in http handlers and responds
+// with an internal server error. If the handler already started writing the response,
+// the connection is aborted instead so the client never gets a half-written response.
+func NewMiddleware(log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(rec)
+				}
+
+				Recovered(r.Context(), log, rec,
+					logger.Any("method", r.Method),
+					logger.Any("path", r.URL.Path),
+					logger.Any("request_id", middleware.GetReqID(r.Context())))
+
+				if ww.Status() != 0 || ww.BytesWritten() > 0 {
+					panic(http.ErrAbortHandler)
+				}
+				responses.Error(r.Context(), ww,
+					responses.NewErrorResponse(http.StatusInternalServerError, "Internal server error"),
+					http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
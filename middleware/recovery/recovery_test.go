@@ -0,0 +1,122 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestLogger() (logger.Logger, *observer.ObservedLogs) {
+	core, observed := observer.New(zap.DebugLevel)
+	return logger.NewWithLogger(zap.New(core)), observed
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	tt := []struct {
+		Name    string
+		Handler http.HandlerFunc
+		Code    int
+		Aborted bool
+	}{
+		{
+			Name: "Panic before writing",
+			Handler: func(http.ResponseWriter, *http.Request) {
+				panic("cow")
+			},
+			Code: http.StatusInternalServerError,
+		},
+		{
+			Name: "Panic after writing aborts the response",
+			Handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				panic(errors.New("cow"))
+			},
+			Aborted: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			log, observed := newTestLogger()
+			h := NewMiddleware(log)(tc.Handler)
+
+			rec := httptest.NewRecorder()
+			serve := func() {
+				h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+
+			if tc.Aborted {
+				assert.PanicsWithValue(t, http.ErrAbortHandler, serve)
+			} else {
+				assert.NotPanics(t, serve)
+				assert.Equal(t, tc.Code, rec.Code)
+				assert.JSONEq(t, `{"code":500,"message":"Internal server error"}`, rec.Body.String())
+			}
+
+			logs := observed.TakeAll()
+			require.Len(t, logs, 1)
+			assert.Equal(t, zap.ErrorLevel, logs[0].Level)
+		})
+	}
+}
+
+func TestRecoveryGRPC(t *testing.T) {
+	log, observed := newTestLogger()
+
+	_, err := UnaryServerInterceptor(log)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/cow.Service/Moo"},
+		func(context.Context, interface{}) (interface{}, error) {
+			panic("cow")
+		})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	err = StreamServerInterceptor(log)(nil, &testStream{}, &grpc.StreamServerInfo{FullMethod: "/cow.Service/Moo"},
+		func(interface{}, grpc.ServerStream) error {
+			panic("cow")
+		})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Len(t, observed.TakeAll(), 2)
+}
+
+type testStream struct {
+	grpc.ServerStream
+}
+
+func (s *testStream) Context() context.Context {
+	return context.Background()
+}
+
+func TestRecoveryTemporal(t *testing.T) {
+	log, observed := newTestLogger()
+
+	suite := testsuite.WorkflowTestSuite{}
+	env := suite.NewTestActivityEnvironment()
+	env.SetWorkerOptions(worker.Options{
+		Interceptors: []interceptor.WorkerInterceptor{NewTemporalInterceptor(log)},
+	})
+	env.RegisterActivity(func(context.Context) error {
+		panic("cow")
+	})
+
+	_, err := env.ExecuteActivity("func1")
+	require.Error(t, err)
+
+	var appErr *temporal.ApplicationError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, PanicErrorType, appErr.Type())
+	assert.Len(t, observed.TakeAll(), 1)
+}
@@ -0,0 +1,50 @@
+package recovery
+
+import (
+	"context"
+
+	"github.com/ConradKurth/gokit/logger"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+)
+
+// PanicErrorType is the application error type of recovered activity panics
+const PanicErrorType = "RecoveredPanic"
+
+// NewTemporalInterceptor returns a worker interceptor that recovers panics in activities
+// and fails the attempt with an application error instead.
+func NewTemporalInterceptor(log logger.Logger) interceptor.WorkerInterceptor {
+	return &workerInterceptor{log: log}
+}
+
+type workerInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	log logger.Logger
+}
+
+func (w *workerInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	i := &activityInterceptor{log: w.log}
+	i.Next = next
+	return i
+}
+
+type activityInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+	log logger.Logger
+}
+
+func (a *activityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			info := activity.GetInfo(ctx)
+			p := Recovered(ctx, a.log, r,
+				logger.Any("activity_type", info.ActivityType.Name),
+				logger.Any("workflow_id", info.WorkflowExecution.ID),
+				logger.Any("run_id", info.WorkflowExecution.RunID),
+				logger.Any("attempt", info.Attempt))
+			result, err = nil, temporal.NewApplicationError(p.Error(), PanicErrorType, string(p.Stack))
+		}
+	}()
+	return a.Next.ExecuteActivity(ctx, in)
+}
@@ -0,0 +1,34 @@
+package region
+
+import (
+	"context"
+
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/ConradKurth/gokit/middleware/grpcutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func fromMetadata(ctx context.Context) (context.Context, error) {
+	ctx, err := loadRegion(ctx, grpcutil.Metadata(ctx, regionHTTPHeader))
+	if err != nil {
+		logger.GetLogger(ctx).ErrorCtx(ctx,
+			"unable to add region from grpc",
+			logger.ErrField(err))
+		return ctx, status.Error(codes.Internal, "unable to add region")
+	}
+	return ctx, nil
+}
+
+// UnaryServerInterceptor returns the grpc counterpart of the region middleware. It reads
+// the region from the x-region metadata.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return grpcutil.UnaryServerInterceptor(fromMetadata)
+}
+
+// StreamServerInterceptor returns the grpc counterpart of the region middleware. It reads
+// the region from the x-region metadata.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return grpcutil.StreamServerInterceptor(fromMetadata)
+}
@@ -0,0 +1,45 @@
+package region_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ConradKurth/gokit/middleware/region"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRegionUnaryServerInterceptor(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Expected region.Region
+		MD       metadata.MD
+	}{
+		{
+			Name:     "Default to US when no metadata set",
+			Expected: region.USA,
+		},
+		{
+			Name:     "Hong kong from the region metadata",
+			Expected: region.HongKong,
+			MD:       metadata.Pairs("x-region", "HongKong"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tc.MD)
+
+			_, err := region.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test/Do"},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					l, err := region.GetRegion(ctx)
+					assert.NoError(t, err)
+					assert.Equal(t, tc.Expected, l)
+					return nil, nil
+				})
+			require.NoError(t, err)
+		})
+	}
+}
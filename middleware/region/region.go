@@ -67,7 +67,7 @@ const regionKey = contextKey("")
 func NewMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx, err := loadRegionFromHeaders(r.Context(), r.Header)
+			ctx, err := loadRegion(r.Context(), r.Header.Get(regionHTTPHeader))
 			if err != nil {
 				logger.GetLogger(r.Context()).ErrorCtx(r.Context(),
 					"unable to add region from http",
@@ -98,8 +98,8 @@ func AddToCtx(ctx context.Context, r Region) (context.Context, error) {
 	return context.WithValue(ctx, regionKey, r), nil
 }
 
-func loadRegionFromHeaders(ctx context.Context, headers http.Header) (context.Context, error) {
-	region := Region(strings.ToLower(headers.Get(regionHTTPHeader)))
+func loadRegion(ctx context.Context, value string) (context.Context, error) {
+	region := Region(strings.ToLower(value))
 	_, ok := regionCurrencies[region]
 	if ok {
 		return AddToCtx(ctx, region)
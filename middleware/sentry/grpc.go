@@ -0,0 +1,75 @@
+package msentry
+
+import (
+	"context"
+
+	"github.com/ConradKurth/gokit/middleware/grpcutil"
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var spanStatuses = map[codes.Code]sentry.SpanStatus{
+	codes.OK:                 sentry.SpanStatusOK,
+	codes.Canceled:           sentry.SpanStatusCanceled,
+	codes.Unknown:            sentry.SpanStatusUnknown,
+	codes.InvalidArgument:    sentry.SpanStatusInvalidArgument,
+	codes.DeadlineExceeded:   sentry.SpanStatusDeadlineExceeded,
+	codes.NotFound:           sentry.SpanStatusNotFound,
+	codes.AlreadyExists:      sentry.SpanStatusAlreadyExists,
+	codes.PermissionDenied:   sentry.SpanStatusPermissionDenied,
+	codes.ResourceExhausted:  sentry.SpanStatusResourceExhausted,
+	codes.FailedPrecondition: sentry.SpanStatusFailedPrecondition,
+	codes.Aborted:            sentry.SpanStatusAborted,
+	codes.OutOfRange:         sentry.SpanStatusOutOfRange,
+	codes.Unimplemented:      sentry.SpanStatusUnimplemented,
+	codes.Internal:           sentry.SpanStatusInternalError,
+	codes.Unavailable:        sentry.SpanStatusUnavailable,
+	codes.DataLoss:           sentry.SpanStatusDataLoss,
+	codes.Unauthenticated:    sentry.SpanStatusUnauthenticated,
+}
+
+// UnaryServerInterceptor returns the grpc counterpart of the sentry middleware. Every call
+// gets its own hub and a transaction named after the method.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, finish := startTransaction(ctx, info.FullMethod)
+		resp, err := handler(ctx, req)
+		finish(err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns the grpc counterpart of the sentry middleware. Every stream
+// gets its own hub and a transaction named after the method.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, finish := startTransaction(ss.Context(), info.FullMethod)
+		err := handler(srv, grpcutil.WrapServerStream(ss, ctx))
+		finish(err)
+		return err
+	}
+}
+
+func startTransaction(ctx context.Context, method string) (context.Context, func(error)) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+	}
+	ctx = sentry.SetHubOnContext(ctx, hub)
+
+	tx := sentry.StartTransaction(ctx, method,
+		sentry.WithOpName("grpc.server"),
+		sentry.WithTransactionSource(sentry.SourceRoute),
+		sentry.ContinueFromHeaders(
+			grpcutil.Metadata(ctx, sentry.SentryTraceHeader),
+			grpcutil.Metadata(ctx, sentry.SentryBaggageHeader)),
+	)
+	hub.Scope().SetTag("grpc.method", method)
+
+	return tx.Context(), func(err error) {
+		tx.Status = spanStatuses[status.Code(err)]
+		tx.Finish()
+	}
+}
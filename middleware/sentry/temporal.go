@@ -0,0 +1,131 @@
+package msentry
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// NewTemporalInterceptor returns the worker counterpart of the sentry middleware. It reports
+// failures that are final: activities failing with a non-retryable error, activities that
+// failed their last attempt as seen by the workflow, and failed workflows. Retried failures
+// are only logged.
+func NewTemporalInterceptor() interceptor.WorkerInterceptor {
+	return &workerInterceptor{}
+}
+
+type workerInterceptor struct {
+	interceptor.WorkerInterceptorBase
+}
+
+func (w *workerInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	i := &activityInterceptor{}
+	i.Next = next
+	return i
+}
+
+func (w *workerInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	i := &workflowInterceptor{}
+	i.Next = next
+	return i
+}
+
+type activityInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+}
+
+func (a *activityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+	}
+	ctx = sentry.SetHubOnContext(ctx, hub)
+
+	result, err := a.Next.ExecuteActivity(ctx, in)
+
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) && appErr.NonRetryable() {
+		info := activity.GetInfo(ctx)
+		capture(hub, err, map[string]string{
+			"temporal.activity_type": info.ActivityType.Name,
+			"temporal.workflow_type": info.WorkflowType.Name,
+			"temporal.workflow_id":   info.WorkflowExecution.ID,
+			"temporal.run_id":        info.WorkflowExecution.RunID,
+			"temporal.attempt":       strconv.Itoa(int(info.Attempt)),
+		})
+	}
+	return result, err
+}
+
+type workflowInterceptor struct {
+	interceptor.WorkflowInboundInterceptorBase
+}
+
+func (w *workflowInterceptor) Init(outbound interceptor.WorkflowOutboundInterceptor) error {
+	o := &workflowOutbound{}
+	o.Next = outbound
+	return w.Next.Init(o)
+}
+
+func (w *workflowInterceptor) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (interface{}, error) {
+	result, err := w.Next.ExecuteWorkflow(ctx, in)
+	// failed activities are already reported by the activity or by ExecuteActivity
+	var activityErr *temporal.ActivityError
+	if errors.As(err, &activityErr) {
+		return result, err
+	}
+	if err != nil && !workflow.IsContinueAsNewError(err) && !temporal.IsCanceledError(err) && !workflow.IsReplaying(ctx) {
+		info := workflow.GetInfo(ctx)
+		capture(sentry.CurrentHub(), err, map[string]string{
+			"temporal.workflow_type": info.WorkflowType.Name,
+			"temporal.workflow_id":   info.WorkflowExecution.ID,
+			"temporal.run_id":        info.WorkflowExecution.RunID,
+		})
+	}
+	return result, err
+}
+
+type workflowOutbound struct {
+	interceptor.WorkflowOutboundInterceptorBase
+}
+
+// ExecuteActivity reports the activity once its future failed, that is after its last attempt
+// or a non-retryable error. Only the error is waited for, the future is returned unchanged so
+// the workflow can still select on it.
+func (o *workflowOutbound) ExecuteActivity(ctx workflow.Context, activityType string, args ...interface{}) workflow.Future {
+	f := o.Next.ExecuteActivity(ctx, activityType, args...)
+
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		err := f.Get(ctx, nil)
+		var activityErr *temporal.ActivityError
+		if !errors.As(err, &activityErr) || temporal.IsCanceledError(err) || workflow.IsReplaying(ctx) {
+			return
+		}
+		// non-retryable application errors are already reported by the activity
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) && appErr.NonRetryable() {
+			return
+		}
+		info := workflow.GetInfo(ctx)
+		capture(sentry.CurrentHub(), err, map[string]string{
+			"temporal.activity_type": activityType,
+			"temporal.workflow_type": info.WorkflowType.Name,
+			"temporal.workflow_id":   info.WorkflowExecution.ID,
+			"temporal.run_id":        info.WorkflowExecution.RunID,
+		})
+	})
+	return f
+}
+
+func capture(hub *sentry.Hub, err error, tags map[string]string) {
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTags(tags)
+		hub.CaptureException(err)
+	})
+}
@@ -0,0 +1,114 @@
+package msentry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+// testTransport keeps the events instead of sending them
+type testTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *testTransport) Flush(_ time.Duration) bool       { return true }
+func (t *testTransport) Configure(_ sentry.ClientOptions) {}
+func (t *testTransport) Close()                           {}
+
+func (t *testTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func TestTemporalInterceptor(t *testing.T) {
+	tt := []struct {
+		Name string
+		// Errors are returned by the attempts of the activity, it succeeds after them
+		Errors      []error
+		WorkflowErr error
+		// Activities are the activity types of the reported events, empty for the workflow
+		Activities []string
+	}{
+		{
+			Name:   "retried until success",
+			Errors: []error{errors.New("flaky")},
+		},
+		{
+			Name:       "failed last attempt",
+			Errors:     []error{errors.New("flaky"), errors.New("flaky")},
+			Activities: []string{"charge"},
+		},
+		{
+			Name:       "non-retryable",
+			Errors:     []error{temporal.NewNonRetryableApplicationError("declined", "declined", nil)},
+			Activities: []string{"charge"},
+		},
+		{
+			Name:        "failed workflow",
+			WorkflowErr: errors.New("invalid order"),
+			Activities:  []string{""},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			transport := &testTransport{}
+			client, err := sentry.NewClient(sentry.ClientOptions{Dsn: "https://key@sentry.example.com/1", Transport: transport})
+			require.NoError(t, err)
+			hub := sentry.CurrentHub()
+			hub.BindClient(client)
+			t.Cleanup(func() {
+				hub.BindClient(nil)
+			})
+
+			attempt := 0
+			charge := func(_ context.Context) error {
+				attempt++
+				if attempt <= len(tc.Errors) {
+					return tc.Errors[attempt-1]
+				}
+				return nil
+			}
+			wf := func(ctx workflow.Context) error {
+				ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+					StartToCloseTimeout: time.Minute,
+					RetryPolicy:         &temporal.RetryPolicy{InitialInterval: time.Millisecond, MaximumAttempts: 2},
+				})
+				if err := workflow.ExecuteActivity(ctx, "charge").Get(ctx, nil); err != nil {
+					return err
+				}
+				return tc.WorkflowErr
+			}
+
+			var s testsuite.WorkflowTestSuite
+			env := s.NewTestWorkflowEnvironment()
+			env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{NewTemporalInterceptor()}})
+			env.RegisterWorkflowWithOptions(wf, workflow.RegisterOptions{Name: "checkout"})
+			env.RegisterActivityWithOptions(charge, activity.RegisterOptions{Name: "charge"})
+
+			env.ExecuteWorkflow("checkout")
+			require.True(t, env.IsWorkflowCompleted())
+
+			var activities []string
+			for _, e := range transport.events {
+				assert.Equal(t, "checkout", e.Tags["temporal.workflow_type"])
+				activities = append(activities, e.Tags["temporal.activity_type"])
+			}
+			assert.Equal(t, tc.Activities, activities)
+		})
+	}
+}
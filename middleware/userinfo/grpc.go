@@ -0,0 +1,24 @@
+package userinfo
+
+import (
+	"context"
+
+	"github.com/ConradKurth/gokit/middleware/grpcutil"
+	"google.golang.org/grpc"
+)
+
+func fromMetadata(ctx context.Context) (context.Context, error) {
+	return addClaims(ctx, grpcutil.Metadata(ctx, "authorization")), nil
+}
+
+// UnaryServerInterceptor returns the grpc counterpart of the user info middleware. It reads
+// the bearer token from the authorization metadata.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return grpcutil.UnaryServerInterceptor(fromMetadata)
+}
+
+// StreamServerInterceptor returns the grpc counterpart of the user info middleware. It reads
+// the bearer token from the authorization metadata.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return grpcutil.StreamServerInterceptor(fromMetadata)
+}
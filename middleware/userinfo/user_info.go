@@ -26,40 +26,42 @@ const (
 func NewMiddleware() func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			splitToken := strings.Split(r.Header.Get("authorization"), "Bearer")
-			if len(splitToken) != 2 {
-				next.ServeHTTP(w, r)
-				return
-			}
-
-			claims := jwt.MapClaims{}
-			authToken := strings.TrimSpace(splitToken[1])
-			parser := jwt.Parser{}
-			_, _, err := parser.ParseUnverified(authToken, claims)
-			if err != nil {
-				logger.GetLoggerReq(r).WarnCtx(r.Context(), "Error parsing claims", logger.ErrField(err))
-				next.ServeHTTP(w, r)
-				return
-			}
-
-			ctx := r.Context()
-			if v, ok := claims["scp"]; ok {
-				ctx = context.WithValue(ctx, scopeKey, v)
-			}
-
-			if v, ok := claims["uid"]; ok {
-				ctx = context.WithValue(ctx, userIDKey, v)
-			}
-
-			if v, ok := claims["groups"]; ok {
-				ctx = context.WithValue(ctx, groupsKey, v)
-			}
-
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(addClaims(r.Context(), r.Header.Get("authorization"))))
 		})
 	}
 }
 
+// addClaims adds the user info from the claims of the bearer token to the context.
+func addClaims(ctx context.Context, authorization string) context.Context {
+	splitToken := strings.Split(authorization, "Bearer")
+	if len(splitToken) != 2 {
+		return ctx
+	}
+
+	claims := jwt.MapClaims{}
+	authToken := strings.TrimSpace(splitToken[1])
+
+	parser := jwt.Parser{}
+	_, _, err := parser.ParseUnverified(authToken, claims)
+	if err != nil {
+		logger.GetLogger(ctx).WarnCtx(ctx, "Error parsing claims", logger.ErrField(err))
+		return ctx
+	}
+
+	if v, ok := claims["scp"]; ok {
+		ctx = context.WithValue(ctx, scopeKey, v)
+	}
+
+	if v, ok := claims["uid"]; ok {
+		ctx = context.WithValue(ctx, userIDKey, v)
+	}
+
+	if v, ok := claims["groups"]; ok {
+		ctx = context.WithValue(ctx, groupsKey, v)
+	}
+	return ctx
+}
+
 // GetUserID finds the userId from the context. REQUIRES Scopes Middleware to have run.
 func GetUserID(ctx context.Context) (string, error) {
 	val, ok := ctx.Value(userIDKey).(string)
@@ -69,6 +71,11 @@ func GetUserID(ctx context.Context) (string, error) {
 	return val, nil
 }
 
+// SetUserID will set the user id on the context, e.g. when it is restored outside of a request
+func SetUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
 // GetScopes finds the scopes from the context. REQUIRES Scopes Middleware to have run.
 func GetScopes(ctx context.Context) ([]string, error) {
 	val, ok := ctx.Value(scopeKey).([]string)
@@ -96,6 +103,16 @@ func GetGroups(ctx context.Context) ([]string, error) {
 	return groups, nil
 }
 
+// SetGroups will set the groups on the context, e.g. when they are restored outside of a request
+func SetGroups(ctx context.Context, groups []string) context.Context {
+	// stored like the claims of the token
+	val := make([]interface{}, 0, len(groups))
+	for _, g := range groups {
+		val = append(val, g)
+	}
+	return context.WithValue(ctx, groupsKey, val)
+}
+
 // IsAdmin returns true if the groups has the admin group in it
 func IsAdmin(ctx context.Context) bool {
 	groups, err := GetGroups(ctx)
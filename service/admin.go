@@ -0,0 +1,53 @@
+package service
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/ConradKurth/gokit/middleware/auth"
+	"github.com/ConradKurth/gokit/responses"
+	"github.com/ConradKurth/gokit/temporalcodec"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// initializeAdminRouter returns the router of the internal admin server. It must never be
+// exposed publicly, it serves profiles and the service configuration.
+func (svc *Service) initializeAdminRouter() chi.Router {
+	router := chi.NewRouter()
+	router.Use(middleware.Heartbeat("/healthz"))
+	router.Use(middleware.Recoverer)
+
+	// pprof under /debug/pprof and expvar under /debug/vars
+	router.Mount("/debug", middleware.Profiler())
+
+	router.Get("/buildinfo", func(w http.ResponseWriter, r *http.Request) {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			responses.Empty(w, http.StatusNotFound)
+			return
+		}
+		responses.Success(r.Context(), w, info)
+	})
+
+	router.Get("/config", func(w http.ResponseWriter, r *http.Request) {
+		responses.Success(r.Context(), w, svc.cfg.Redacted())
+	})
+
+	if levelHandler, ok := logger.LevelHandler(svc.logger); ok {
+		router.Method(http.MethodGet, "/loglevel", levelHandler)
+		router.Method(http.MethodPut, "/loglevel", levelHandler)
+	}
+
+	// codec server for the Temporal UI and CLI, it decrypts payloads so it requires auth
+	if len(svc.payloadCodecs) > 0 {
+		router.Route("/codec", func(r chi.Router) {
+			// auth runs inside CORS, so the preflight requests of the Temporal UI are answered
+			r.Handle("/*", temporalcodec.NewHandler(svc.payloadCodecs, svc.cfg.GetStringSlice("temporal.codecServer.origins"),
+				auth.NewMiddleware(svc.cfg)))
+		})
+	}
+
+	return router
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/ConradKurth/gokit/temporalcodec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AdminRouter(t *testing.T) {
+	svc := newTestService(map[string]interface{}{
+		"serviceName": "cow",
+		"logtail":     map[string]interface{}{"token": "secret"},
+	})
+	router := svc.initializeAdminRouter()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var cfg map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	assert.Equal(t, "cow", cfg["servicename"])
+	assert.Equal(t, map[string]interface{}{"token": config.RedactedValue}, cfg["logtail"])
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func Test_AdminRouter_CodecServer(t *testing.T) {
+	svc := newTestService(map[string]interface{}{
+		"auth":     map[string]interface{}{"admin": map[string]interface{}{"key": "admin"}},
+		"temporal": map[string]interface{}{"codecServer": map[string]interface{}{"origins": []string{"https://temporal.example.com"}}},
+	})
+	router := svc.initializeAdminRouter()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/codec/decode", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	codecs, err := temporalcodec.NewCodecs(temporalcodec.Options{
+		Keys:        map[string][]byte{"k1": make([]byte, 32)},
+		ActiveKeyID: "k1",
+	})
+	require.NoError(t, err)
+	svc.payloadCodecs = codecs
+	router = svc.initializeAdminRouter()
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/codec/decode", strings.NewReader(`{"payloads":[]}`)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/codec/decode", strings.NewReader(`{"payloads":[]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Admin-Key", "admin")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// the browser sends the preflight of the Temporal UI without credentials
+	req = httptest.NewRequest(http.MethodOptions, "/codec/decode", nil)
+	req.Header.Set("Origin", "https://temporal.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "Authorization, Content-Type, X-Namespace")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "https://temporal.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/worker"
+	"google.golang.org/grpc"
+)
+
+const (
+	temporalWorkerComponent = "temporal-worker"
+	grpcServerComponent     = "grpc-server"
+	httpServerComponent     = "http-server"
+	privateServerComponent  = "private-http-server"
+	adminServerComponent    = "admin-server"
+)
+
+// Component is a long running unit of work managed by the service lifecycle,
+// e.g. a server, a queue consumer or a cron loop.
+type Component interface {
+	// Name uniquely identifies the component within the service.
+	Name() string
+	// Start runs the component. It should block until the component has
+	// finished or the context is cancelled.
+	Start(ctx context.Context) error
+	// Stop gracefully stops the component. The context carries the deadline
+	// the component has to finish.
+	Stop(ctx context.Context) error
+}
+
+// ReadyNotifier can be implemented by a component to signal when it is ready.
+// Components depending on it are only started once the channel is closed.
+// Components not implementing it are ready as soon as they are started.
+type ReadyNotifier interface {
+	Ready() <-chan struct{}
+}
+
+type componentOptions struct {
+	dependsOn   []string
+	stopTimeout time.Duration
+	// ownStopBudget stops the component within its stop timeout even when the drain budget
+	// of the shutdown is used up
+	ownStopBudget bool
+}
+
+// DependsOn will start the component only after the named components are ready
+// and stop it before them.
+func DependsOn(names ...string) func(*componentOptions) {
+	return func(o *componentOptions) {
+		o.dependsOn = append(o.dependsOn, names...)
+	}
+}
+
+// StopTimeout sets the deadline the component has to stop during shutdown.
+func StopTimeout(d time.Duration) func(*componentOptions) {
+	return func(o *componentOptions) {
+		o.stopTimeout = d
+	}
+}
+
+// ownStopBudget gives the component its whole stop timeout, independent of the drain budget
+func ownStopBudget() func(*componentOptions) {
+	return func(o *componentOptions) {
+		o.ownStopBudget = true
+	}
+}
+
+type registeredComponent struct {
+	component Component
+	opts      componentOptions
+}
+
+// temporalComponent runs the temporal worker.
+type temporalComponent struct {
+	name    string
+	worker  worker.Worker
+	stopped chan struct{}
+	once    sync.Once
+
+	// lock makes a stop wait for a starting worker, stopping keeps it from starting afterwards
+	lock     sync.Mutex
+	stopping bool
+}
+
+func newTemporalComponent(name string, w worker.Worker) *temporalComponent {
+	return &temporalComponent{
+		name:    name,
+		worker:  w,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (c *temporalComponent) Name() string {
+	return c.name
+}
+
+// Start returns right away when the component was stopped before it started
+func (c *temporalComponent) Start(ctx context.Context) error {
+	c.lock.Lock()
+	if c.stopping {
+		c.lock.Unlock()
+		return nil
+	}
+	err := c.worker.Start()
+	c.lock.Unlock()
+	if err != nil {
+		return fmt.Errorf("starting temporal worker: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-c.stopped:
+	}
+	return nil
+}
+
+// Stop waits for the worker to finish its activities within its stop timeout, the worker keeps
+// stopping in the background when the context is done first.
+func (c *temporalComponent) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.once.Do(func() {
+			c.lock.Lock()
+			c.stopping = true
+			c.lock.Unlock()
+
+			c.worker.Stop()
+			close(c.stopped)
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stopping temporal worker: %w", ctx.Err())
+	}
+}
+
+// grpcComponent runs the grpc server.
+type grpcComponent struct {
+	server *grpc.Server
+	host   string
+	ready  chan struct{}
+}
+
+func newGRPCComponent(server *grpc.Server, host string) *grpcComponent {
+	return &grpcComponent{
+		server: server,
+		host:   host,
+		ready:  make(chan struct{}),
+	}
+}
+
+func (c *grpcComponent) Name() string {
+	return grpcServerComponent
+}
+
+func (c *grpcComponent) Ready() <-chan struct{} {
+	return c.ready
+}
+
+func (c *grpcComponent) Start(_ context.Context) error {
+	lis, err := net.Listen("tcp", c.host)
+	if err != nil {
+		return fmt.Errorf("creating listener socket: %w", err)
+	}
+	close(c.ready)
+
+	if err := c.server.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("serving grpc: %w", err)
+	}
+	return nil
+}
+
+func (c *grpcComponent) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		c.server.Stop()
+		return fmt.Errorf("stopping grpc server: %w", ctx.Err())
+	}
+}
+
+// httpComponent runs a http webserver.
+type httpComponent struct {
+	name   string
+	server *http.Server
+	ready  chan struct{}
+}
+
+func newHTTPComponent(name string, server *http.Server) *httpComponent {
+	return &httpComponent{
+		name:   name,
+		server: server,
+		ready:  make(chan struct{}),
+	}
+}
+
+func (c *httpComponent) Name() string {
+	return c.name
+}
+
+func (c *httpComponent) Ready() <-chan struct{} {
+	return c.ready
+}
+
+func (c *httpComponent) Start(_ context.Context) error {
+	lis, err := net.Listen("tcp", c.server.Addr)
+	if err != nil {
+		return fmt.Errorf("creating listener socket: %w", err)
+	}
+	close(c.ready)
+
+	serve := c.server.Serve
+	if c.server.TLSConfig != nil {
+		// the certificates come from the tls config
+		serve = func(lis net.Listener) error {
+			return c.server.ServeTLS(lis, "", "")
+		}
+	}
+	if err := serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("starting webserver: %w", err)
+	}
+	return nil
+}
+
+func (c *httpComponent) Stop(ctx context.Context) error {
+	if err := c.server.Shutdown(ctx); err != nil {
+		return errors.Join(err, c.server.Close())
+	}
+	return nil
+}
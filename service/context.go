@@ -8,7 +8,7 @@ import (
 )
 
 // Context returns a context that is cancelled automatically when a SIGINT,
-// SIGQUIT or SIGTERM signal is received.
+// SIGQUIT or SIGTERM signal is received. A second signal exits the process.
 func Context() context.Context {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
@@ -16,6 +16,8 @@ func Context() context.Context {
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		<-sig
+		// restore the default handling so a second signal kills the process
+		signal.Stop(sig)
 		cancel()
 	}()
 
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+	"sync"
+
+	"github.com/ConradKurth/gokit/responses"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const gatewayComponent = "grpc-gateway"
+
+// headers forwarded to the grpc metadata so the interceptors see the same values
+// as the http middleware
+var gatewayHeaders = map[string]bool{
+	"X-Region":     true,
+	"X-Currency":   true,
+	"X-Admin-Key":  true,
+	"X-Request-Id": true,
+}
+
+// GatewayRegistration registers the http handlers of a grpc service with the gateway. The
+// generated `Register<Service>Handler` functions can be passed directly.
+type GatewayRegistration func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+type gatewayOptions struct {
+	prefix        string
+	registrations []GatewayRegistration
+	muxOptions    []runtime.ServeMuxOption
+}
+
+// inProcessKey marks the context of calls of the gateway
+type inProcessKey struct{}
+
+// initializeGateway mounts a grpc-gateway mux on the public router below the prefix. The mux
+// calls the grpc server in process, so requests go through the http middleware and the grpc
+// interceptors.
+func (svc *Service) initializeGateway(ctx context.Context, o *gatewayOptions) error {
+	if len(o.registrations) == 0 {
+		return errors.New("no gateway registrations, use WithGRPCGateway")
+	}
+
+	// the grpc server is served through ServeHTTP over h2c, so its transport credentials do
+	// not apply to the in process connection
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			svc.grpcServer.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), inProcessKey{}, true)))
+		}),
+		Protocols: &http.Protocols{},
+	}
+	server.Protocols.SetUnencryptedHTTP2(true)
+
+	lis := newPipeListener()
+	conn, err := grpc.NewClient("passthrough:///"+gatewayComponent,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("connecting gateway: %w", err)
+	}
+
+	mux := runtime.NewServeMux(append([]runtime.ServeMuxOption{
+		runtime.WithErrorHandler(gatewayErrorHandler),
+		runtime.WithRoutingErrorHandler(gatewayRoutingErrorHandler),
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+	}, o.muxOptions...)...)
+	for _, register := range o.registrations {
+		if err := register(ctx, mux, conn); err != nil {
+			return errors.Join(fmt.Errorf("registering gateway handler: %w", err), conn.Close())
+		}
+	}
+
+	// the mux matches the full path, so it is not mounted as a sub router
+	svc.router.Handle(o.prefix+"/*", mux)
+
+	return svc.registerServer(&gatewayServer{server: server, lis: lis, conn: conn},
+		DependsOn(grpcServerComponent))
+}
+
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if gatewayHeaders[textproto.CanonicalMIMEHeaderKey(key)] {
+		return key, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayErrorHandler writes the grpc status as an error response
+func gatewayErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+	s := status.Convert(err)
+	code := runtime.HTTPStatusFromCode(s.Code())
+	responses.Error(ctx, w, responses.NewErrorResponse(code, s.Message()), code)
+}
+
+func gatewayRoutingErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, code int) {
+	responses.Error(ctx, w, responses.NewErrorResponse(code, http.StatusText(code)), code)
+}
+
+// gatewayServer serves the grpc server on the in process listener of the gateway
+type gatewayServer struct {
+	server *http.Server
+	lis    *pipeListener
+	conn   *grpc.ClientConn
+}
+
+func (g *gatewayServer) Name() string {
+	return gatewayComponent
+}
+
+func (g *gatewayServer) Start(_ context.Context) error {
+	if err := g.server.Serve(g.lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving grpc gateway: %w", err)
+	}
+	return nil
+}
+
+func (g *gatewayServer) Stop(ctx context.Context) error {
+	// the http server is already stopped, so no requests use the connection anymore
+	err := g.conn.Close()
+	if shutdownErr := g.server.Shutdown(ctx); shutdownErr != nil {
+		return errors.Join(err, shutdownErr, g.server.Close())
+	}
+	return err
+}
+
+// pipeListener is an in memory listener, dialing it returns one end of a pipe and
+// accepting the other
+type pipeListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) DialContext(ctx context.Context) (net.Conn, error) {
+	client, server := net.Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.closed:
+		return nil, errors.Join(net.ErrClosed, client.Close(), server.Close())
+	case <-ctx.Done():
+		return nil, errors.Join(ctx.Err(), client.Close(), server.Close())
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() {
+		close(l.closed)
+	})
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return gatewayComponent }
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ConradKurth/gokit/responses"
+	"github.com/go-chi/chi/v5"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// registerHealthGateway does what a generated Register<Service>Handler does for the health service
+func registerHealthGateway(_ context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+	client := healthpb.NewHealthClient(conn)
+	return mux.HandlePath(http.MethodGet, "/v1/health/{service}", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+		resp, err := client.Check(r.Context(), &healthpb.HealthCheckRequest{Service: params["service"]})
+		if err != nil {
+			runtime.HTTPError(r.Context(), mux, outbound, w, r, err)
+			return
+		}
+		runtime.ForwardResponseMessage(r.Context(), mux, outbound, w, r, resp)
+	})
+}
+
+// newTestServerCreds returns the credentials of a self signed certificate
+// newTestCert returns a self-signed certificate and key for localhost as PEM
+func newTestCert(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func newTestServerCreds(t *testing.T) credentials.TransportCredentials {
+	t.Helper()
+
+	certPEM, keyPEM := newTestCert(t)
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	require.NoError(t, err)
+	return credentials.NewServerTLSFromCert(&cert)
+}
+
+func Test_Gateway(t *testing.T) {
+	svc := newTestService(nil)
+	svc.router = chi.NewRouter()
+	var inProcess bool
+	// the gateway has to work with the tls of the grpc server outside local
+	svc.grpcServer = grpc.NewServer(
+		grpc.Creds(newTestServerCreds(t)),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			inProcess = isInProcess(ctx, "")
+			return handler(ctx, req)
+		}),
+	)
+	hs := health.NewServer()
+	hs.SetServingStatus("users", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(svc.grpcServer, hs)
+
+	ctx := context.Background()
+	require.NoError(t, svc.RegisterComponent(newGRPCComponent(svc.grpcServer, "127.0.0.1:0")))
+	require.NoError(t, svc.initializeGateway(ctx, &gatewayOptions{
+		prefix:        "/v1",
+		registrations: []GatewayRegistration{registerHealthGateway},
+	}))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- svc.Start(ctx)
+	}()
+	defer func() {
+		require.NoError(t, svc.stopComponents(ctx))
+		require.NoError(t, <-errCh)
+	}()
+
+	rec := httptest.NewRecorder()
+	svc.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/health/users", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"SERVING"}`, rec.Body.String())
+	assert.True(t, inProcess)
+
+	rec = httptest.NewRecorder()
+	svc.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/health/orders", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	var body responses.ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, http.StatusNotFound, body.Code)
+	assert.Equal(t, "unknown service", body.Message)
+
+	rec = httptest.NewRecorder()
+	svc.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/nope", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
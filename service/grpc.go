@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/ConradKurth/gokit/middleware/auth"
+	"github.com/ConradKurth/gokit/middleware/currencies"
+	loggerMiddleware "github.com/ConradKurth/gokit/middleware/logger"
+	"github.com/ConradKurth/gokit/middleware/mtls"
+	"github.com/ConradKurth/gokit/middleware/recovery"
+	"github.com/ConradKurth/gokit/middleware/region"
+	sentryMiddleware "github.com/ConradKurth/gokit/middleware/sentry"
+	"github.com/ConradKurth/gokit/middleware/userinfo"
+	"github.com/ConradKurth/gokit/tlsconfig"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Names of the grpc interceptors that can be enabled with WithGRPCInterceptors. They
+// always run in the order below, after tracing and before panic recovery.
+const (
+	InterceptorSentry   = "sentry"
+	InterceptorUserInfo = "userInfo"
+	InterceptorLogger   = "logger"
+	InterceptorAuth     = "auth"
+	InterceptorRegion   = "region"
+	InterceptorCurrency = "currency"
+)
+
+var interceptorOrder = []string{
+	InterceptorSentry,
+	InterceptorUserInfo,
+	InterceptorLogger,
+	InterceptorAuth,
+	InterceptorRegion,
+	InterceptorCurrency,
+}
+
+// grpcServerCredentials returns the transport credentials of the grpc server from `grpc.tls`.
+// Plaintext is only allowed when running locally.
+func (svc *Service) grpcServerCredentials(cfg *config.Config) (credentials.TransportCredentials, error) {
+	o := svc.tlsOptions(cfg, "grpc.tls")
+	if !o.Enabled {
+		if !config.IsLocal() {
+			return nil, errors.New("grpc.tls.enabled is required outside of local")
+		}
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsCfg, err := tlsconfig.NewServerConfig(o)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// grpcClientCredentials returns the transport credentials of outbound grpc connections from
+// `grpc.client.tls`. Plaintext is only allowed when running locally.
+func (svc *Service) grpcClientCredentials(cfg *config.Config, path string) (credentials.TransportCredentials, error) {
+	o := svc.tlsOptions(cfg, path)
+	if !o.Enabled {
+		if !config.IsLocal() {
+			return nil, fmt.Errorf("%s.enabled is required outside of local", path)
+		}
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsCfg, err := tlsconfig.NewClientConfig(o)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+func (svc *Service) tlsOptions(cfg *config.Config, path string) tlsconfig.Options {
+	o := tlsconfig.FromConfig(cfg, path)
+	o.OnReloadError = func(err error) {
+		svc.logger.WarnCtx(context.Background(), "Unable to reload certificates, keeping the current ones",
+			logger.ErrField(err),
+			logger.Any("config", path))
+	}
+	return o
+}
+
+// grpcInterceptors returns the chained server interceptors for the enabled names
+func (svc *Service) grpcInterceptors(cfg *config.Config, enabled []string) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor, error) {
+	selected := map[string]bool{}
+	for _, name := range enabled {
+		selected[name] = true
+	}
+
+	unary := []grpc.UnaryServerInterceptor{otelgrpc.UnaryServerInterceptor()}
+	stream := []grpc.StreamServerInterceptor{otelgrpc.StreamServerInterceptor()}
+	if o := tlsconfig.FromConfig(cfg, "grpc.tls"); o.Enabled && o.ClientAuth {
+		// calls of the gateway are in process and have no client certificate
+		unary = append(unary, skipUnary(mtls.UnaryServerInterceptor(), isInProcess))
+		stream = append(stream, skipStream(mtls.StreamServerInterceptor(), isInProcess))
+	}
+
+	for _, name := range interceptorOrder {
+		if !selected[name] {
+			continue
+		}
+		delete(selected, name)
+
+		switch name {
+		case InterceptorSentry:
+			unary = append(unary, sentryMiddleware.UnaryServerInterceptor())
+			stream = append(stream, sentryMiddleware.StreamServerInterceptor())
+		case InterceptorUserInfo:
+			unary = append(unary, userinfo.UnaryServerInterceptor())
+			stream = append(stream, userinfo.StreamServerInterceptor())
+		case InterceptorLogger:
+			unary = append(unary, loggerMiddleware.UnaryServerInterceptor(svc.logger))
+			stream = append(stream, loggerMiddleware.StreamServerInterceptor(svc.logger))
+		case InterceptorAuth:
+			// health checks come from the orchestrator which has no credentials
+			unary = append(unary, skipUnary(auth.UnaryServerInterceptor(cfg), isHealthMethod))
+			stream = append(stream, skipStream(auth.StreamServerInterceptor(cfg), isHealthMethod))
+		case InterceptorRegion:
+			unary = append(unary, region.UnaryServerInterceptor())
+			stream = append(stream, region.StreamServerInterceptor())
+		case InterceptorCurrency:
+			unary = append(unary, currencies.UnaryServerInterceptor())
+			stream = append(stream, currencies.StreamServerInterceptor())
+		}
+	}
+
+	for name := range selected {
+		return nil, nil, fmt.Errorf("unknown grpc interceptor %q", name)
+	}
+
+	// innermost like in the http stack, so a panic is logged and reported as a failed call
+	unary = append(unary, recovery.UnaryServerInterceptor(svc.logger))
+	stream = append(stream, recovery.StreamServerInterceptor(svc.logger))
+	return unary, stream, nil
+}
+
+func isHealthMethod(_ context.Context, method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+func isInProcess(ctx context.Context, _ string) bool {
+	inProcess, _ := ctx.Value(inProcessKey{}).(bool)
+	return inProcess
+}
+
+// skipUnary only runs the interceptor for calls that are not skipped
+func skipUnary(next grpc.UnaryServerInterceptor, skip func(ctx context.Context, method string) bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if skip(ctx, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		return next(ctx, req, info, handler)
+	}
+}
+
+// skipStream only runs the interceptor for streams that are not skipped
+func skipStream(next grpc.StreamServerInterceptor, skip func(ctx context.Context, method string) bool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if skip(ss.Context(), info.FullMethod) {
+			return handler(srv, ss)
+		}
+		return next(srv, ss, info, handler)
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_GRPCInterceptors(t *testing.T) {
+	svc := newTestService(nil)
+
+	unary, stream, err := svc.grpcInterceptors(svc.cfg, nil)
+	require.NoError(t, err)
+	// tracing and recovery are always installed
+	assert.Len(t, unary, 2)
+	assert.Len(t, stream, 2)
+
+	unary, stream, err = svc.grpcInterceptors(svc.cfg, []string{InterceptorRegion, InterceptorLogger, InterceptorRegion})
+	require.NoError(t, err)
+	assert.Len(t, unary, 4)
+	assert.Len(t, stream, 4)
+
+	_, _, err = svc.grpcInterceptors(svc.cfg, []string{"nope"})
+	assert.EqualError(t, err, `unknown grpc interceptor "nope"`)
+}
+
+func Test_GRPCInterceptors_RecoveryInnermost(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	svc := newTestService(nil)
+	svc.logger = logger.NewWithLogger(zap.New(core))
+
+	unary, _, err := svc.grpcInterceptors(svc.cfg, []string{InterceptorLogger})
+	require.NoError(t, err)
+
+	var handler grpc.UnaryHandler = func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Panic"}
+	for i := len(unary) - 1; i >= 0; i-- {
+		interceptor, next := unary[i], handler
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+
+	_, err = handler(context.Background(), nil)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	// the logger sees the recovered panic as a failed call
+	calls := logs.FilterField(zap.String("type", "request")).All()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "Internal", calls[0].ContextMap()["code"])
+}
+
+func Test_IsHealthMethod(t *testing.T) {
+	assert.True(t, isHealthMethod(context.Background(), "/grpc.health.v1.Health/Check"))
+	assert.False(t, isHealthMethod(context.Background(), "/api.v1.Users/Get"))
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ConradKurth/gokit/logger"
+	"golang.org/x/sync/errgroup"
+)
+
+// RegisterComponent registers a component that is started by Start and stopped by Shutdown.
+// Components are started concurrently, respecting their dependencies, and stopped in reverse order.
+func (svc *Service) RegisterComponent(c Component, opts ...func(*componentOptions)) error {
+	o := componentOptions{
+		stopTimeout: shutdownTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	svc.lock.Lock()
+	defer svc.lock.Unlock()
+
+	if svc.running {
+		return fmt.Errorf("registering component %s: service is already running", c.Name())
+	}
+	for _, rc := range svc.components {
+		if rc.component.Name() == c.Name() {
+			return fmt.Errorf("registering component %s: duplicate name", c.Name())
+		}
+	}
+
+	svc.components = append(svc.components, &registeredComponent{
+		component: c,
+		opts:      o,
+	})
+	return nil
+}
+
+// registerServer registers a server that may use the whole drain budget to finish its
+// in-flight requests
+func (svc *Service) registerServer(c Component, opts ...func(*componentOptions)) error {
+	opts = append([]func(*componentOptions){StopTimeout(getDrainTimeout(svc.cfg))}, opts...)
+	return svc.RegisterComponent(c, opts...)
+}
+
+// orderComponents sorts the components so every component comes after its dependencies.
+// Components without an ordering constraint keep their registration order.
+func orderComponents(components []*registeredComponent) ([]*registeredComponent, error) {
+	byName := make(map[string]*registeredComponent, len(components))
+	for _, rc := range components {
+		byName[rc.component.Name()] = rc
+	}
+
+	for _, rc := range components {
+		for _, dep := range rc.opts.dependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("component %s depends on unknown component %s", rc.component.Name(), dep)
+			}
+		}
+	}
+
+	ordered := make([]*registeredComponent, 0, len(components))
+	placed := make(map[string]bool, len(components))
+	for len(ordered) < len(components) {
+		progress := false
+		for _, rc := range components {
+			if placed[rc.component.Name()] {
+				continue
+			}
+
+			resolved := true
+			for _, dep := range rc.opts.dependsOn {
+				if !placed[dep] {
+					resolved = false
+					break
+				}
+			}
+			if !resolved {
+				continue
+			}
+
+			placed[rc.component.Name()] = true
+			ordered = append(ordered, rc)
+			progress = true
+		}
+
+		if !progress {
+			return nil, errors.New("components have a dependency cycle")
+		}
+	}
+
+	return ordered, nil
+}
+
+// runComponents starts all components and blocks until all of them have returned.
+// The first component that fails triggers the shutdown of all others.
+func (svc *Service) runComponents(ctx context.Context) error {
+	svc.lock.Lock()
+	if svc.stopped {
+		// shut down before it was started
+		svc.lock.Unlock()
+		return nil
+	}
+	ordered, err := orderComponents(svc.components)
+	if err != nil {
+		svc.lock.Unlock()
+		return err
+	}
+	// components are stopped through Shutdown, not by cancelling the caller's context,
+	// so that they stop in reverse order.
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	svc.ordered = ordered
+	svc.cancelRun = cancel
+	svc.running = true
+	svc.lock.Unlock()
+	defer cancel()
+
+	launched := make(map[string]chan struct{}, len(ordered))
+	ready := make(map[string]<-chan struct{}, len(ordered))
+	for _, rc := range ordered {
+		name := rc.component.Name()
+		launched[name] = make(chan struct{})
+		ready[name] = launched[name]
+		if r, ok := rc.component.(ReadyNotifier); ok {
+			ready[name] = r.Ready()
+		}
+	}
+
+	g, gctx := errgroup.WithContext(runCtx)
+	for _, rc := range ordered {
+		g.Go(func() error {
+			name := rc.component.Name()
+			for _, dep := range rc.opts.dependsOn {
+				select {
+				case <-ready[dep]:
+				case <-gctx.Done():
+					return nil
+				}
+			}
+
+			svc.logger.InfoCtx(ctx, "Starting component", logger.Any("component", name))
+			close(launched[name])
+			if err := rc.component.Start(gctx); err != nil {
+				svc.logger.ErrorCtx(ctx, "Component failed", logger.Any("component", name), logger.ErrField(err))
+				go func() {
+					if err := svc.stopComponents(context.WithoutCancel(ctx)); err != nil {
+						svc.logger.ErrorCtx(ctx, "Error stopping components", logger.ErrField(err))
+					}
+				}()
+				return fmt.Errorf("running component %s: %w", name, err)
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		// wait for the shutdown triggered by the failing component to finish
+		_ = svc.stopComponents(context.WithoutCancel(ctx))
+		return err
+	}
+	return nil
+}
+
+// stopComponents stops all components in reverse start order, giving each one its own deadline.
+// It only runs once, later calls return the result of the first one.
+func (svc *Service) stopComponents(ctx context.Context) error {
+	svc.stopOnce.Do(func() {
+		svc.lock.Lock()
+		ordered := svc.ordered
+		if ordered == nil {
+			var err error
+			if ordered, err = orderComponents(svc.components); err != nil {
+				ordered = svc.components
+			}
+		}
+		cancel := svc.cancelRun
+		svc.stopped = true
+		svc.lock.Unlock()
+
+		for i := len(ordered) - 1; i >= 0; i-- {
+			rc := ordered[i]
+			name := rc.component.Name()
+			svc.logger.InfoCtx(ctx, "Stopping component", logger.Any("component", name))
+
+			parent := ctx
+			if rc.opts.ownStopBudget {
+				parent = context.WithoutCancel(ctx)
+			}
+			stopCtx, stopCancel := context.WithTimeout(parent, rc.opts.stopTimeout)
+			if err := rc.component.Stop(stopCtx); err != nil {
+				svc.stopErr = errors.Join(svc.stopErr, fmt.Errorf("stopping component %s: %w", name, err))
+			}
+			stopCancel()
+		}
+
+		if cancel != nil {
+			cancel()
+		}
+	})
+
+	return svc.stopErr
+}
+
+// waitForWorkers waits until the temporal workers stopping in the background have stopped or
+// the context is done
+func (svc *Service) waitForWorkers(ctx context.Context) {
+	svc.lock.Lock()
+	components := svc.components
+	svc.lock.Unlock()
+
+	for _, rc := range components {
+		c, ok := rc.component.(*temporalComponent)
+		if !ok {
+			continue
+		}
+		select {
+		case <-c.stopped:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/ConradKurth/gokit/health"
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(e string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) get() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+type testComponent struct {
+	name     string
+	startErr error
+	rec      *recorder
+	stopped  chan struct{}
+	once     sync.Once
+}
+
+func newTestComponent(name string, rec *recorder) *testComponent {
+	return &testComponent{name: name, rec: rec, stopped: make(chan struct{})}
+}
+
+func (c *testComponent) Name() string {
+	return c.name
+}
+
+func (c *testComponent) Start(ctx context.Context) error {
+	c.rec.add("start " + c.name)
+	if c.startErr != nil {
+		return c.startErr
+	}
+	select {
+	case <-ctx.Done():
+	case <-c.stopped:
+	}
+	return nil
+}
+
+func (c *testComponent) Stop(_ context.Context) error {
+	c.once.Do(func() {
+		c.rec.add("stop " + c.name)
+		close(c.stopped)
+	})
+	return nil
+}
+
+func newTestService(cfg map[string]interface{}) *Service {
+	return &Service{
+		cfg:    config.LoadConfig(config.WithMap(cfg)),
+		logger: logger.NewNoop(),
+		health: health.New(),
+	}
+}
+
+func Test_OrderComponents(t *testing.T) {
+	rec := &recorder{}
+	tt := []struct {
+		Name        string
+		Components  []*registeredComponent
+		Expected    []string
+		ErrExpected bool
+	}{
+		{
+			Name: "Registration order without dependencies",
+			Components: []*registeredComponent{
+				{component: newTestComponent("a", rec)},
+				{component: newTestComponent("b", rec)},
+			},
+			Expected: []string{"a", "b"},
+		},
+		{
+			Name: "Dependencies come first",
+			Components: []*registeredComponent{
+				{component: newTestComponent("a", rec), opts: componentOptions{dependsOn: []string{"c"}}},
+				{component: newTestComponent("b", rec)},
+				{component: newTestComponent("c", rec), opts: componentOptions{dependsOn: []string{"b"}}},
+			},
+			Expected: []string{"b", "c", "a"},
+		},
+		{
+			Name: "Unknown dependency",
+			Components: []*registeredComponent{
+				{component: newTestComponent("a", rec), opts: componentOptions{dependsOn: []string{"cow"}}},
+			},
+			ErrExpected: true,
+		},
+		{
+			Name: "Dependency cycle",
+			Components: []*registeredComponent{
+				{component: newTestComponent("a", rec), opts: componentOptions{dependsOn: []string{"b"}}},
+				{component: newTestComponent("b", rec), opts: componentOptions{dependsOn: []string{"a"}}},
+			},
+			ErrExpected: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ordered, err := orderComponents(tc.Components)
+			if tc.ErrExpected {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			names := make([]string, 0, len(ordered))
+			for _, rc := range ordered {
+				names = append(names, rc.component.Name())
+			}
+			assert.Equal(t, tc.Expected, names)
+		})
+	}
+}
+
+func Test_Lifecycle_Shutdown(t *testing.T) {
+	rec := &recorder{}
+	svc := newTestService(nil)
+	require.NoError(t, svc.RegisterComponent(newTestComponent("a", rec)))
+	require.NoError(t, svc.RegisterComponent(newTestComponent("b", rec), DependsOn("a")))
+	assert.Error(t, svc.RegisterComponent(newTestComponent("a", rec)))
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- svc.Start(context.Background())
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(rec.get()) == 2
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, svc.Shutdown(context.Background()))
+	require.NoError(t, <-errs)
+	assert.Equal(t, []string{"start a", "start b", "stop b", "stop a"}, rec.get())
+}
+
+func Test_Lifecycle_FailureStopsOthers(t *testing.T) {
+	rec := &recorder{}
+	svc := newTestService(nil)
+
+	failing := newTestComponent("b", rec)
+	failing.startErr = errors.New("boom")
+	require.NoError(t, svc.RegisterComponent(newTestComponent("a", rec)))
+	require.NoError(t, svc.RegisterComponent(failing, DependsOn("a")))
+
+	err := svc.Start(context.Background())
+	assert.ErrorContains(t, err, "boom")
+	assert.Contains(t, rec.get(), "stop a")
+}
+
+type drainCheckComponent struct {
+	*testComponent
+	health *health.Registry
+}
+
+func (c *drainCheckComponent) Stop(ctx context.Context) error {
+	if !c.health.IsDraining() {
+		c.rec.add("not draining")
+	}
+	return c.testComponent.Stop(ctx)
+}
+
+func Test_Shutdown_Drains(t *testing.T) {
+	rec := &recorder{}
+	svc := newTestService(map[string]interface{}{
+		"shutdown": map[string]interface{}{"drainTimeoutSecs": 1},
+	})
+	require.NoError(t, svc.RegisterComponent(&drainCheckComponent{
+		testComponent: newTestComponent("a", rec),
+		health:        svc.health,
+	}))
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- svc.Start(context.Background())
+	}()
+	require.Eventually(t, func() bool {
+		return len(rec.get()) == 1
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, svc.Shutdown(context.Background()))
+	require.NoError(t, <-errs)
+	assert.Equal(t, []string{"start a", "stop a"}, rec.get())
+	assert.Equal(t, health.StatusFailing, svc.health.Readiness(context.Background()).Status)
+}
+
+func Test_RegisterServer_DrainTimeout(t *testing.T) {
+	svc := newTestService(map[string]interface{}{
+		"shutdown": map[string]interface{}{"drainTimeoutSecs": 45},
+	})
+	require.NoError(t, svc.registerServer(newTestComponent("server", &recorder{})))
+	require.NoError(t, svc.RegisterComponent(newTestComponent("other", &recorder{})))
+
+	assert.Equal(t, 45*time.Second, svc.components[0].opts.stopTimeout)
+	assert.Equal(t, shutdownTimeout, svc.components[1].opts.stopTimeout)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/ConradKurth/gokit/tlsconfig"
+	"google.golang.org/grpc"
+)
+
+// multiplexServer serves the grpc server through the webserver on `api.port`. HTTP/1.1, h2c
+// and, with `grpc.tls` enabled, HTTP/2 over TLS are accepted and grpc calls are routed to the
+// grpc server by their content type. Client certificates of `grpc.tls.clientAuth` can not be
+// required as they would be required for the http routes too.
+func (svc *Service) multiplexServer(cfg *config.Config, server *http.Server) error {
+	server.Handler = multiplexHandler(svc.grpcServer, server.Handler)
+
+	server.Protocols = &http.Protocols{}
+	server.Protocols.SetHTTP1(true)
+	server.Protocols.SetHTTP2(true)
+	server.Protocols.SetUnencryptedHTTP2(true)
+
+	// the transport credentials of the grpc server are not used by ServeHTTP
+	if o := svc.tlsOptions(cfg, "grpc.tls"); o.Enabled {
+		if o.ClientAuth {
+			return errors.New("grpc.tls.clientAuth is not supported by the multiplexed server, it would require client certificates for http")
+		}
+		tlsCfg, err := tlsconfig.NewServerConfig(o)
+		if err != nil {
+			return fmt.Errorf("initializing tls: %w", err)
+		}
+		server.TLSConfig = tlsCfg
+	}
+	return nil
+}
+
+// multiplexHandler sends grpc calls to the grpc server and everything else to next
+func multiplexHandler(grpcServer *grpc.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isGRPCRequest(r) {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isGRPCRequest(r *http.Request) bool {
+	if r.ProtoMajor != 2 {
+		return false
+	}
+	ct := r.Header.Get("Content-Type")
+	// grpc-web is not supported by the grpc server and left to the router
+	return ct == "application/grpc" || strings.HasPrefix(ct, "application/grpc+") || strings.HasPrefix(ct, "application/grpc;")
+}
+
+// multiplexedGRPCComponent stops the grpc server that is served by the webserver. It has
+// to be registered before the webserver: the server can only stop gracefully once the
+// webserver finished all calls.
+type multiplexedGRPCComponent struct {
+	server  *grpc.Server
+	once    sync.Once
+	stopped chan struct{}
+}
+
+func newMultiplexedGRPCComponent(server *grpc.Server) *multiplexedGRPCComponent {
+	return &multiplexedGRPCComponent{
+		server:  server,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (c *multiplexedGRPCComponent) Name() string {
+	return grpcServerComponent
+}
+
+func (c *multiplexedGRPCComponent) Start(_ context.Context) error {
+	<-c.stopped
+	return nil
+}
+
+func (c *multiplexedGRPCComponent) Stop(_ context.Context) error {
+	c.once.Do(func() {
+		c.server.GracefulStop()
+		close(c.stopped)
+	})
+	return nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+	return lis.Addr().String()
+}
+
+func Test_MultiplexedServer(t *testing.T) {
+	t.Setenv("GO_ENV", "local")
+	svc := newTestService(nil)
+	svc.grpcServer = grpc.NewServer()
+	healthpb.RegisterHealthServer(svc.grpcServer, health.NewServer())
+
+	router := chi.NewRouter()
+	router.Get("/ping", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	addr := freeAddr(t)
+	server := &http.Server{Addr: addr, Handler: router}
+	require.NoError(t, svc.multiplexServer(svc.cfg, server))
+
+	require.NoError(t, svc.RegisterComponent(newMultiplexedGRPCComponent(svc.grpcServer)))
+	c := newHTTPComponent(httpServerComponent, server)
+	require.NoError(t, svc.RegisterComponent(c))
+
+	ctx := context.Background()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- svc.Start(ctx)
+	}()
+	<-c.Ready()
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/ping", addr))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, 1, resp.ProtoMajor)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	check, err := healthpb.NewHealthClient(conn).Check(checkCtx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check.Status)
+
+	require.NoError(t, svc.stopComponents(ctx))
+	require.NoError(t, <-errCh)
+}
+
+func Test_MultiplexedServer_TLS(t *testing.T) {
+	certPEM, keyPEM := newTestCert(t)
+	svc := newTestService(map[string]interface{}{
+		"grpc": map[string]interface{}{
+			"tls": map[string]interface{}{"enabled": true, "cert": certPEM, "key": keyPEM},
+		},
+	})
+	svc.grpcServer = grpc.NewServer()
+	healthpb.RegisterHealthServer(svc.grpcServer, health.NewServer())
+
+	router := chi.NewRouter()
+	router.Get("/ping", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	addr := freeAddr(t)
+	server := &http.Server{Addr: addr, Handler: router}
+	require.NoError(t, svc.multiplexServer(svc.cfg, server))
+
+	require.NoError(t, svc.RegisterComponent(newMultiplexedGRPCComponent(svc.grpcServer)))
+	c := newHTTPComponent(httpServerComponent, server)
+	require.NoError(t, svc.RegisterComponent(c))
+
+	ctx := context.Background()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- svc.Start(ctx)
+	}()
+	<-c.Ready()
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM([]byte(certPEM)))
+	clientCfg := &tls.Config{RootCAs: roots, ServerName: "localhost", MinVersion: tls.VersionTLS12}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCfg}}
+	resp, err := client.Get(fmt.Sprintf("https://%s/ping", addr))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(clientCfg)))
+	require.NoError(t, err)
+	defer conn.Close()
+	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	check, err := healthpb.NewHealthClient(conn).Check(checkCtx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check.Status)
+
+	require.NoError(t, svc.stopComponents(ctx))
+	require.NoError(t, <-errCh)
+}
+
+func Test_MultiplexedServer_ClientAuth(t *testing.T) {
+	certPEM, keyPEM := newTestCert(t)
+	svc := newTestService(map[string]interface{}{
+		"grpc": map[string]interface{}{
+			"tls": map[string]interface{}{"enabled": true, "cert": certPEM, "key": keyPEM, "ca": certPEM, "clientAuth": true},
+		},
+	})
+	svc.grpcServer = grpc.NewServer()
+
+	err := svc.multiplexServer(svc.cfg, &http.Server{Handler: chi.NewRouter()})
+	assert.ErrorContains(t, err, "grpc.tls.clientAuth")
+}
+
+func Test_IsGRPCRequest(t *testing.T) {
+	tt := []struct {
+		Name        string
+		ProtoMajor  int
+		ContentType string
+		Expected    bool
+	}{
+		{Name: "grpc", ProtoMajor: 2, ContentType: "application/grpc", Expected: true},
+		{Name: "grpc with codec", ProtoMajor: 2, ContentType: "application/grpc+proto", Expected: true},
+		{Name: "grpc-web", ProtoMajor: 2, ContentType: "application/grpc-web"},
+		{Name: "json over http2", ProtoMajor: 2, ContentType: "application/json"},
+		{Name: "grpc over http1", ProtoMajor: 1, ContentType: "application/grpc"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			r := &http.Request{ProtoMajor: tc.ProtoMajor, Header: http.Header{"Content-Type": []string{tc.ContentType}}}
+			assert.Equal(t, tc.Expected, isGRPCRequest(r))
+		})
+	}
+}
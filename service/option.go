@@ -1,16 +1,33 @@
 package service
 
-import "github.com/getsentry/sentry-go"
+import (
+	"net/http"
+
+	"github.com/ConradKurth/gokit/grpcclient"
+	"github.com/ConradKurth/gokit/temporalctx"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
 
 type options struct {
 	temporalService       bool
+	temporalContextFields []temporalctx.Field
 	httpService           bool
 	grpcService           bool
+	grpcInterceptors      []string
+	grpcClientOptions     []grpcclient.Option
+	gateway               *gatewayOptions
+	multiplex             bool
+	sentryDSN             string
+	reloadHook            ReloadHook
+	adminService          bool
 	traceSampleRate       float64
 	sentryEnabled         bool
 	traceSampler          sentry.TracesSampler
 	beforeSendTransaction func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event
 	beforeSendEvent       func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event
+	router                routerOptions
 }
 
 // WithGRPCService will enable to service to run with a temporal worker
@@ -20,6 +37,52 @@ func WithGRPCService() func(*options) {
 	}
 }
 
+// WithGRPCInterceptors enables the named grpc interceptors, see the Interceptor constants.
+// They mirror the http middleware and always run in the same order.
+func WithGRPCInterceptors(names ...string) func(*options) {
+	return func(o *options) {
+		o.grpcInterceptors = append(o.grpcInterceptors, names...)
+	}
+}
+
+// WithGRPCClientOptions configures the grpc client registry, e.g. with per upstream interceptors
+func WithGRPCClientOptions(opts ...grpcclient.Option) func(*options) {
+	return func(o *options) {
+		o.grpcClientOptions = append(o.grpcClientOptions, opts...)
+	}
+}
+
+// WithGRPCGateway mounts a grpc-gateway mux below the prefix of the public router, e.g. "/v1".
+// It requires the grpc and http service.
+func WithGRPCGateway(prefix string, registrations ...GatewayRegistration) func(*options) {
+	return func(o *options) {
+		if o.gateway == nil {
+			o.gateway = &gatewayOptions{}
+		}
+		o.gateway.prefix = prefix
+		o.gateway.registrations = append(o.gateway.registrations, registrations...)
+	}
+}
+
+// WithGatewayMuxOptions adds options to the grpc-gateway mux, e.g. custom marshalers
+func WithGatewayMuxOptions(opts ...runtime.ServeMuxOption) func(*options) {
+	return func(o *options) {
+		if o.gateway == nil {
+			o.gateway = &gatewayOptions{}
+		}
+		o.gateway.muxOptions = append(o.gateway.muxOptions, opts...)
+	}
+}
+
+// WithMultiplexedServer serves http and grpc on the single listener of `api.port` instead of
+// a separate one for `grpc.host`. It requires the grpc and http service and does not support
+// `grpc.tls.clientAuth`.
+func WithMultiplexedServer() func(*options) {
+	return func(o *options) {
+		o.multiplex = true
+	}
+}
+
 // WithTemporalService will enable to service to run with a temporal worker
 func WithTemporalService() func(*options) {
 	return func(o *options) {
@@ -27,6 +90,14 @@ func WithTemporalService() func(*options) {
 	}
 }
 
+// WithTemporalContextFields propagates the fields of the context to workflows and activities,
+// the request values of the gokit middleware are always propagated
+func WithTemporalContextFields(fields ...temporalctx.Field) func(*options) {
+	return func(o *options) {
+		o.temporalContextFields = append(o.temporalContextFields, fields...)
+	}
+}
+
 // WithHTTPService will enable to service to run with an http service
 func WithHTTPService() func(*options) {
 	return func(o *options) {
@@ -34,6 +105,14 @@ func WithHTTPService() func(*options) {
 	}
 }
 
+// WithAdminServer will start an internal admin http server on `admin.port` with
+// profiling, expvar, build info, the redacted config and log level control
+func WithAdminServer() func(*options) {
+	return func(o *options) {
+		o.adminService = true
+	}
+}
+
 func WithTraceSampleRate(r float64) func(*options) {
 	return func(o *options) {
 		o.traceSampleRate = r
@@ -58,8 +137,73 @@ func WithBeforeSendEvent(t func(event *sentry.Event, hint *sentry.EventHint) *se
 	}
 }
 
+// WithSentryDSN sets the sentry dsn, it takes precedence over the one passed to New
+func WithSentryDSN(dsn string) func(o *options) {
+	return func(o *options) {
+		o.sentryDSN = dsn
+	}
+}
+
 func WithSentry(enabled bool) func(o *options) {
 	return func(o *options) {
 		o.sentryEnabled = enabled
 	}
 }
+
+// WithMiddlewareEnabled enables or disables a built-in router middleware by name,
+// overriding `middleware.<name>.enabled`
+func WithMiddlewareEnabled(name string, enabled bool) func(*options) {
+	return func(o *options) {
+		if o.router.enabled == nil {
+			o.router.enabled = map[string]bool{}
+		}
+		o.router.enabled[name] = enabled
+	}
+}
+
+// WithCORS sets the allowed CORS methods and headers, overriding `cors.methods` and `cors.headers`
+func WithCORS(methods, headers []string) func(*options) {
+	return func(o *options) {
+		o.router.corsMethods = methods
+		o.router.corsHeaders = headers
+	}
+}
+
+// WithTrustedProxies sets the proxies, as CIDRs or IPs, the real ip middleware trusts to
+// forward the client address, overriding `middleware.realIP.trustedProxies`
+func WithTrustedProxies(proxies ...string) func(*options) {
+	return func(o *options) {
+		o.router.trustedProxies = proxies
+	}
+}
+
+// WithCompression sets the minimum response size and the algorithms, in order of preference,
+// used for compression, overriding `middleware.compression.minSize` and `middleware.compression.algorithms`
+func WithCompression(minSize int, algorithms ...string) func(*options) {
+	return func(o *options) {
+		o.router.compressionMinSize = &minSize
+		if len(algorithms) > 0 {
+			o.router.compressionAlgorithms = algorithms
+		}
+	}
+}
+
+// WithMiddlewareBefore inserts custom middleware before the named built-in middleware
+func WithMiddlewareBefore(name string, middlewares ...func(http.Handler) http.Handler) func(*options) {
+	return func(o *options) {
+		if o.router.before == nil {
+			o.router.before = map[string][]func(http.Handler) http.Handler{}
+		}
+		o.router.before[name] = append(o.router.before[name], middlewares...)
+	}
+}
+
+// WithMiddlewareAfter inserts custom middleware after the named built-in middleware
+func WithMiddlewareAfter(name string, middlewares ...func(http.Handler) http.Handler) func(*options) {
+	return func(o *options) {
+		if o.router.after == nil {
+			o.router.after = map[string][]func(http.Handler) http.Handler{}
+		}
+		o.router.after[name] = append(o.router.after[name], middlewares...)
+	}
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/ConradKurth/gokit/health"
+	"github.com/ConradKurth/gokit/middleware/compress"
+	"github.com/ConradKurth/gokit/middleware/mtls"
+	"github.com/ConradKurth/gokit/middleware/realip"
+	"github.com/ConradKurth/gokit/middleware/recovery"
+	sentryMiddleware "github.com/ConradKurth/gokit/middleware/sentry"
+	"github.com/ConradKurth/gokit/middleware/ssl"
+	"github.com/ConradKurth/gokit/tlsconfig"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+	"github.com/riandyrn/otelchi"
+)
+
+// Names of the built-in router middleware, in the order they run. A middleware can be
+// disabled with the `middleware.<name>.enabled` config key or WithMiddlewareEnabled, and
+// the names are the positions custom middleware is inserted at.
+const (
+	MiddlewareHeartbeat   = "heartbeat"
+	MiddlewareHealth      = "health"
+	MiddlewareSSL         = "ssl"
+	MiddlewareCORS        = "cors"
+	MiddlewareTracing     = "tracing"
+	MiddlewareRealIP      = "realIP"
+	MiddlewareRequestID   = "requestID"
+	MiddlewareCompression = "compression"
+	MiddlewareSentry      = "sentry"
+	MiddlewareRecovery    = "recovery"
+	// MiddlewareAuth is the service-to-service auth through client certificates, only used on
+	// the private router
+	MiddlewareAuth = "auth"
+)
+
+var defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+type routerOptions struct {
+	enabled               map[string]bool
+	corsMethods           []string
+	corsHeaders           []string
+	trustedProxies        []string
+	compressionAlgorithms []string
+	compressionMinSize    *int
+	before                map[string][]func(http.Handler) http.Handler
+	after                 map[string][]func(http.Handler) http.Handler
+}
+
+var middlewareNames = map[string]bool{
+	MiddlewareHeartbeat: true, MiddlewareHealth: true, MiddlewareSSL: true, MiddlewareCORS: true,
+	MiddlewareTracing: true, MiddlewareRealIP: true, MiddlewareRequestID: true,
+	MiddlewareCompression: true, MiddlewareSentry: true, MiddlewareRecovery: true, MiddlewareAuth: true,
+}
+
+// validate returns an error for options naming a middleware that is not built in, so a typo
+// does not silently drop the custom middleware or keep a middleware enabled
+func (o routerOptions) validate() error {
+	var unknown []string
+	check := func(name string) {
+		if !middlewareNames[name] && !slices.Contains(unknown, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	for name := range o.enabled {
+		check(name)
+	}
+	for name := range o.before {
+		check(name)
+	}
+	for name := range o.after {
+		check(name)
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown router middleware: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+type namedMiddleware struct {
+	name    string
+	handler func(http.Handler) http.Handler
+}
+
+// initializeRouter returns a router with standard health and middleware configured.
+func (svc *Service) initializeRouter(cfg *config.Config) (chi.Router, error) {
+	corsMethods := svc.routerOpts.corsMethods
+	if corsMethods == nil {
+		corsMethods = cfg.GetStringSlice("cors.methods")
+	}
+	if len(corsMethods) == 0 {
+		corsMethods = defaultCORSMethods
+	}
+	corsHeaders := svc.routerOpts.corsHeaders
+	if corsHeaders == nil {
+		corsHeaders = cfg.GetStringSlice("cors.headers")
+	}
+	if len(corsHeaders) == 0 {
+		corsHeaders = []string{"*"}
+	}
+	maxAge := cfg.GetInt("cors.maxAge")
+	if maxAge == 0 {
+		maxAge = 300
+	}
+
+	cors := cors.New(cors.Options{
+		AllowedOrigins:   cfg.GetStringSlice("cors.hosts"),
+		AllowedMethods:   corsMethods,
+		AllowedHeaders:   corsHeaders,
+		AllowCredentials: cfg.GetBoolDefault("cors.allowCredentials", true),
+		MaxAge:           maxAge,
+	})
+
+	common, err := svc.commonMiddleware(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	stack := []namedMiddleware{
+		{MiddlewareHeartbeat, svc.health.NewHeartbeat(health.HeartbeatPath)},
+		{MiddlewareHealth, svc.health.NewMiddleware()},
+		{MiddlewareSSL, ssl.NewMiddleware(config.IsLocal())},
+		{MiddlewareCORS, cors.Handler},
+	}
+	return svc.buildRouter(cfg, append(stack, common...)), nil
+}
+
+// initializePrivateRouter returns a router for internal routes. It has no CORS or SSL redirect
+// and requires service-to-service auth unless `api.private.auth` is disabled: callers present a
+// client certificate verified against the CA of `api.private.tls`, limited to the names of
+// `api.private.allowedPeers` when set. Plaintext without auth is only allowed when running locally.
+func (svc *Service) initializePrivateRouter(cfg *config.Config) (chi.Router, error) {
+	common, err := svc.commonMiddleware(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	stack := []namedMiddleware{
+		{MiddlewareHeartbeat, svc.health.NewHeartbeat(health.HeartbeatPath)},
+		{MiddlewareHealth, svc.health.NewMiddleware()},
+	}
+	stack = append(stack, common...)
+	if cfg.GetBoolDefault("api.private.auth", true) {
+		o := tlsconfig.FromConfig(cfg, "api.private.tls")
+		switch {
+		case o.Enabled && o.ClientAuth:
+			stack = append(stack, namedMiddleware{MiddlewareAuth, mtls.NewMiddleware(cfg.GetStringSlice("api.private.allowedPeers")...)})
+		case !config.IsLocal():
+			return nil, errors.New("api.private.auth requires api.private.tls.clientAuth outside of local")
+		}
+	}
+	return svc.buildRouter(cfg, stack), nil
+}
+
+// commonMiddleware returns the middleware shared by the public and private routers.
+func (svc *Service) commonMiddleware(cfg *config.Config) ([]namedMiddleware, error) {
+	trustedProxies := svc.routerOpts.trustedProxies
+	if trustedProxies == nil {
+		trustedProxies = cfg.GetStringSlice("middleware.realIP.trustedProxies")
+	}
+	realIP, err := realip.NewMiddleware(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("initializing real ip middleware: %w", err)
+	}
+
+	compressOpts := compress.Options{
+		Level:      cfg.GetInt("middleware.compression.level"),
+		Algorithms: svc.routerOpts.compressionAlgorithms,
+		MinSize:    cfg.GetInt("middleware.compression.minSize"),
+	}
+	if compressOpts.Level == 0 {
+		compressOpts.Level = brotliCompressionLevel
+	}
+	if compressOpts.Algorithms == nil {
+		compressOpts.Algorithms = cfg.GetStringSlice("middleware.compression.algorithms")
+	}
+	if svc.routerOpts.compressionMinSize != nil {
+		compressOpts.MinSize = *svc.routerOpts.compressionMinSize
+	}
+	compression, err := compress.NewMiddleware(compressOpts)
+	if err != nil {
+		return nil, fmt.Errorf("initializing compression middleware: %w", err)
+	}
+
+	return []namedMiddleware{
+		// sample anything below
+		{MiddlewareTracing, otelchi.Middleware(svc.serviceName)},
+		{MiddlewareRealIP, realIP},
+		{MiddlewareRequestID, middleware.RequestID},
+		{MiddlewareCompression, compression},
+		{MiddlewareSentry, sentryMiddleware.NewMiddleware(cfg)},
+		// innermost so handler panics are reported once and never leak a half-written response
+		{MiddlewareRecovery, recovery.NewMiddleware(svc.logger)},
+	}, nil
+}
+
+// buildRouter adds the enabled middleware of the stack and the custom middleware around it.
+func (svc *Service) buildRouter(cfg *config.Config, stack []namedMiddleware) chi.Router {
+	router := chi.NewRouter()
+	for _, m := range stack {
+		router.Use(svc.routerOpts.before[m.name]...)
+
+		enabled, ok := svc.routerOpts.enabled[m.name]
+		if !ok {
+			enabled = cfg.GetBoolDefault(fmt.Sprintf("middleware.%s.enabled", m.name), true)
+		}
+		if enabled {
+			router.Use(m.handler)
+		}
+
+		router.Use(svc.routerOpts.after[m.name]...)
+	}
+	return router
+}
+
+// RegisterRoutes registers internal http routes with the private webserver router.
+func (svc *Service) RegisterRoutes(routers []RouteRegistration, middlewares ...func(http.Handler) http.Handler) {
+	router := svc.PrivateRouter()
+	for _, route := range routers {
+		route.RegisterRoutes(router, middlewares...)
+	}
+}
+
+// RegisterPublicRoutes registers http routes with the public webserver router.
+func (svc *Service) RegisterPublicRoutes(routers []RouteRegistration, middlewares ...func(http.Handler) http.Handler) {
+
+	for _, route := range routers {
+		route.RegisterPublicRoutes(svc.router, middlewares...)
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRoutes struct{}
+
+func (testRoutes) RegisterRoutes(router chi.Router, middlewares ...func(http.Handler) http.Handler) {
+	router.With(middlewares...).Get("/internal", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func (testRoutes) RegisterPublicRoutes(router chi.Router, middlewares ...func(http.Handler) http.Handler) {
+	router.With(middlewares...).Get("/public", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func Test_PrivateRoutes(t *testing.T) {
+	t.Setenv("GO_ENV", "local")
+	svc := newTestService(map[string]interface{}{
+		"api": map[string]interface{}{"private": map[string]interface{}{
+			"tls":          map[string]interface{}{"enabled": true, "clientAuth": true},
+			"allowedPeers": []string{"orders"},
+		}},
+	})
+	var err error
+	svc.router, err = svc.initializeRouter(svc.cfg)
+	require.NoError(t, err)
+	svc.privateRouter, err = svc.initializePrivateRouter(svc.cfg)
+	require.NoError(t, err)
+
+	svc.RegisterRoutes([]RouteRegistration{testRoutes{}})
+	svc.RegisterPublicRoutes([]RouteRegistration{testRoutes{}})
+
+	peer := func(cn string) *tls.ConnectionState {
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}}}
+	}
+
+	tt := []struct {
+		Name   string
+		Router http.Handler
+		Path   string
+		TLS    *tls.ConnectionState
+		Code   int
+	}{
+		{
+			Name:   "Public route on the public router",
+			Router: svc.router,
+			Path:   "/public",
+			Code:   http.StatusOK,
+		},
+		{
+			Name:   "Internal route is not on the public router",
+			Router: svc.router,
+			Path:   "/internal",
+			Code:   http.StatusNotFound,
+		},
+		{
+			Name:   "Internal route requires auth",
+			Router: svc.privateRouter,
+			Path:   "/internal",
+			Code:   http.StatusUnauthorized,
+		},
+		{
+			Name:   "Internal route with an allowed client certificate",
+			Router: svc.privateRouter,
+			Path:   "/internal",
+			TLS:    peer("orders"),
+			Code:   http.StatusOK,
+		},
+		{
+			Name:   "Internal route with another client certificate",
+			Router: svc.privateRouter,
+			Path:   "/internal",
+			TLS:    peer("billing"),
+			Code:   http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.Path, nil)
+			req.TLS = tc.TLS
+			rec := httptest.NewRecorder()
+			tc.Router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.Code, rec.Code)
+		})
+	}
+}
+
+func Test_PrivateRouter_RequiresClientAuth(t *testing.T) {
+	t.Setenv("GO_ENV", "production")
+	svc := newTestService(nil)
+	_, err := svc.initializePrivateRouter(svc.cfg)
+	assert.EqualError(t, err, "api.private.auth requires api.private.tls.clientAuth outside of local")
+
+	svc = newTestService(map[string]interface{}{
+		"api": map[string]interface{}{"private": map[string]interface{}{"auth": false}},
+	})
+	_, err = svc.initializePrivateRouter(svc.cfg)
+	assert.NoError(t, err)
+}
+
+func Test_MiddlewareStack(t *testing.T) {
+	t.Setenv("GO_ENV", "development")
+	svc := newTestService(map[string]interface{}{
+		"middleware": map[string]interface{}{"compression": map[string]interface{}{"enabled": false}},
+	})
+
+	var order []string
+	mark := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	opt := options{}
+	for _, o := range []func(*options){
+		WithMiddlewareEnabled(MiddlewareSSL, false),
+		WithMiddlewareBefore(MiddlewareCORS, mark("before cors")),
+		WithMiddlewareAfter(MiddlewareSentry, mark("after sentry")),
+	} {
+		o(&opt)
+	}
+	svc.routerOpts = opt.router
+	router, err := svc.initializeRouter(svc.cfg)
+	require.NoError(t, err)
+	router.Get("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "br")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	// the ssl redirect is disabled outside of local
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, []string{"before cors", "after sentry"}, order)
+}
+
+func Test_RouterOptions_Validate(t *testing.T) {
+	noop := func(next http.Handler) http.Handler { return next }
+
+	tests := []struct {
+		name string
+		opts []func(*options)
+		err  string
+	}{
+		{
+			name: "known names",
+			opts: []func(*options){
+				WithMiddlewareEnabled(MiddlewareCompression, false),
+				WithMiddlewareBefore(MiddlewareAuth, noop),
+				WithMiddlewareAfter(MiddlewareCORS, noop),
+			},
+		},
+		{
+			name: "unknown names",
+			opts: []func(*options){
+				WithMiddlewareEnabled("compresion", false),
+				WithMiddlewareBefore("cors", noop),
+				WithMiddlewareAfter("requestId", noop),
+				WithMiddlewareBefore("requestId", noop),
+			},
+			err: "unknown router middleware: compresion, requestId",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options{}
+			for _, opt := range tt.opts {
+				opt(&o)
+			}
+			err := o.router.validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func Test_Heartbeat_Draining(t *testing.T) {
+	t.Setenv("GO_ENV", "local")
+	svc := newTestService(nil)
+	router, err := svc.initializeRouter(svc.cfg)
+	require.NoError(t, err)
+	privateRouter, err := svc.initializePrivateRouter(svc.cfg)
+	require.NoError(t, err)
+	// chi only runs the middleware once a route is registered
+	svc.router, svc.privateRouter = router, privateRouter
+	svc.RegisterRoutes([]RouteRegistration{testRoutes{}})
+	svc.RegisterPublicRoutes([]RouteRegistration{testRoutes{}})
+
+	heartbeat := func(router http.Handler) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, heartbeat(router))
+	assert.Equal(t, http.StatusOK, heartbeat(privateRouter))
+
+	svc.health.Drain()
+	assert.Equal(t, http.StatusServiceUnavailable, heartbeat(router))
+	assert.Equal(t, http.StatusServiceUnavailable, heartbeat(privateRouter))
+}
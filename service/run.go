@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ConradKurth/gokit/logger"
+)
+
+// Exit codes of Run
+const (
+	ExitOK = 0
+	// ExitError is used when the service could not be set up, a component failed or the
+	// shutdown returned an error
+	ExitError = 1
+	// ExitForced is used when a second signal interrupted the shutdown
+	ExitForced = 2
+)
+
+// ReloadHook is called when the process receives a SIGHUP
+type ReloadHook func(ctx context.Context, svc *Service) error
+
+// WithReloadHook sets the function Run calls on SIGHUP, e.g. to reload certificates or
+// feature flags. Without it SIGHUP is ignored.
+func WithReloadHook(fn ReloadHook) func(*options) {
+	return func(o *options) {
+		o.reloadHook = fn
+	}
+}
+
+// Run creates the service, calls setup to register routes and workers and runs the service
+// until the context is cancelled, a component fails or SIGINT, SIGQUIT or SIGTERM is
+// received. It then shuts the service down and exits the process, a second signal during
+// the shutdown exits immediately.
+func Run(ctx context.Context, configPath string, setup func(*Service) error, opts ...func(*options)) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP)
+
+	code := run(ctx, func(ctx context.Context) (*Service, error) {
+		return New(ctx, configPath, "", opts...)
+	}, setup, opts, sig)
+
+	signal.Stop(sig)
+	os.Exit(code)
+}
+
+func run(ctx context.Context, newService func(context.Context) (*Service, error), setup func(*Service) error, opts []func(*options), sig <-chan os.Signal) int {
+	opt := options{}
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	svc, err := newService(ctx)
+	if err != nil {
+		// there is no logger without a service
+		fmt.Fprintf(os.Stderr, "creating service: %v\n", err)
+		return ExitError
+	}
+
+	if err := setup(svc); err != nil {
+		svc.logger.ErrorCtx(ctx, "Setting up service", logger.ErrField(err))
+		if err := svc.Shutdown(context.WithoutCancel(ctx)); err != nil {
+			svc.logger.ErrorCtx(ctx, "Shutting down service", logger.ErrField(err))
+		}
+		return ExitError
+	}
+
+	started := make(chan error, 1)
+	go func() {
+		started <- svc.Start(ctx)
+	}()
+
+	code := ExitOK
+	var reason string
+wait:
+	for {
+		select {
+		case s := <-sig:
+			if s == syscall.SIGHUP {
+				svc.reload(ctx, opt.reloadHook)
+				continue
+			}
+			reason = "received " + s.String()
+			break wait
+		case err := <-started:
+			reason = "components stopped"
+			if err != nil {
+				reason = "component failed: " + err.Error()
+				code = ExitError
+			}
+			break wait
+		case <-ctx.Done():
+			reason = "context cancelled"
+			break wait
+		}
+	}
+
+	svc.logger.InfoCtx(ctx, "Shutting down", logger.Any("reason", reason))
+
+	// the shutdown has its own deadlines and must not be cut short by the cancelled context
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Shutdown(context.WithoutCancel(ctx))
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				// the logger is closed by now
+				fmt.Fprintf(os.Stderr, "shutting down: %v\n", err)
+				return ExitError
+			}
+			return code
+		case s := <-sig:
+			if s == syscall.SIGHUP {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "received %s during shutdown, forcing exit\n", s)
+			return ExitForced
+		}
+	}
+}
+
+func (svc *Service) reload(ctx context.Context, hook ReloadHook) {
+	if hook == nil {
+		svc.logger.InfoCtx(ctx, "Ignoring SIGHUP, no reload hook set")
+		return
+	}
+
+	svc.logger.InfoCtx(ctx, "Reloading")
+	if err := hook(ctx, svc); err != nil {
+		svc.logger.ErrorCtx(ctx, "Reloading failed", logger.ErrField(err))
+	}
+}
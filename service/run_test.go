@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingComponent never finishes stopping until it is released
+type blockingComponent struct {
+	*testComponent
+	stopping chan struct{}
+	release  chan struct{}
+}
+
+func (c *blockingComponent) Stop(ctx context.Context) error {
+	close(c.stopping)
+	<-c.release
+	return c.testComponent.Stop(ctx)
+}
+
+func Test_Run(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Setup    func(svc *Service) error
+		StartErr error
+		Signals  []os.Signal
+		Expected int
+		Events   []string
+		Reloads  int
+	}{
+		{
+			Name:     "signal shuts down",
+			Signals:  []os.Signal{syscall.SIGTERM},
+			Expected: ExitOK,
+			Events:   []string{"start test", "stop test"},
+		},
+		{
+			Name:     "sighup reloads",
+			Signals:  []os.Signal{syscall.SIGHUP, syscall.SIGHUP, syscall.SIGINT},
+			Expected: ExitOK,
+			Events:   []string{"start test", "stop test"},
+			Reloads:  2,
+		},
+		{
+			Name:     "component failure",
+			StartErr: errors.New("boom"),
+			Expected: ExitError,
+			Events:   []string{"start test", "stop test"},
+		},
+		{
+			Name: "setup failure",
+			Setup: func(*Service) error {
+				return errors.New("boom")
+			},
+			Expected: ExitError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := &recorder{}
+			reloads := 0
+			sig := make(chan os.Signal)
+			if len(tc.Signals) > 0 {
+				go func() {
+					// signals arrive once the service is running
+					for !slices.Contains(rec.get(), "start test") {
+						time.Sleep(time.Millisecond)
+					}
+					for _, s := range tc.Signals {
+						sig <- s
+					}
+				}()
+			}
+
+			setup := func(svc *Service) error {
+				c := newTestComponent("test", rec)
+				c.startErr = tc.StartErr
+				return svc.RegisterComponent(c)
+			}
+			if tc.Setup != nil {
+				setup = tc.Setup
+			}
+
+			code := run(context.Background(), func(context.Context) (*Service, error) {
+				return newTestService(nil), nil
+			}, setup, []func(*options){WithReloadHook(func(context.Context, *Service) error {
+				reloads++
+				return nil
+			})}, sig)
+
+			assert.Equal(t, tc.Expected, code)
+			assert.Equal(t, tc.Events, rec.get())
+			assert.Equal(t, tc.Reloads, reloads)
+		})
+	}
+}
+
+func Test_Run_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	code := run(ctx, func(context.Context) (*Service, error) {
+		return newTestService(nil), nil
+	}, func(*Service) error { return nil }, nil, make(chan os.Signal))
+	assert.Equal(t, ExitOK, code)
+}
+
+func Test_Run_SecondSignalForcesExit(t *testing.T) {
+	c := &blockingComponent{
+		testComponent: newTestComponent("test", &recorder{}),
+		stopping:      make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	defer close(c.release)
+
+	sig := make(chan os.Signal, 1)
+	sig <- syscall.SIGTERM
+	// the second signal arrives once the shutdown started
+	go func() {
+		<-c.stopping
+		sig <- syscall.SIGTERM
+	}()
+
+	code := run(context.Background(), func(context.Context) (*Service, error) {
+		return newTestService(nil), nil
+	}, func(svc *Service) error {
+		return svc.RegisterComponent(c)
+	}, nil, sig)
+	require.Equal(t, ExitForced, code)
+}
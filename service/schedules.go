@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/ConradKurth/gokit/temporalschedule"
+)
+
+const schedulesComponent = "temporal-schedules"
+
+// RegisterSchedules declares temporal schedules of the service. On start they are reconciled:
+// missing schedules are created, changed ones updated and schedules of the service that are
+// no longer declared are deleted. With `temporal.schedules.dryRun` the changes are only logged.
+func (svc *Service) RegisterSchedules(schedules ...temporalschedule.Schedule) error {
+	if svc.temporalClient == nil {
+		return errors.New("schedules require the temporal service")
+	}
+
+	svc.lock.Lock()
+	first := svc.schedules == nil
+	svc.schedules = append(svc.schedules, schedules...)
+	if svc.schedules == nil {
+		// registering none still deletes the schedules of the service
+		svc.schedules = []temporalschedule.Schedule{}
+	}
+	svc.lock.Unlock()
+
+	if !first {
+		return nil
+	}
+	return svc.RegisterComponent(&schedulesComponentRunner{svc: svc})
+}
+
+// ReconcileSchedules reconciles the registered schedules and returns the changes. With dryRun
+// nothing is changed.
+func (svc *Service) ReconcileSchedules(ctx context.Context, dryRun bool) ([]temporalschedule.Change, error) {
+	if svc.temporalClient == nil {
+		return nil, errors.New("schedules require the temporal service")
+	}
+
+	svc.lock.Lock()
+	schedules := append([]temporalschedule.Schedule(nil), svc.schedules...)
+	svc.lock.Unlock()
+
+	r := temporalschedule.NewReconciler(svc.temporalClient.ScheduleClient(), svc.serviceName, svc.cfg.GetString("temporal.taskQueue"))
+	changes, err := r.Reconcile(ctx, schedules, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("reconciling schedules: %w", err)
+	}
+
+	msg := "Applied schedule change"
+	if dryRun {
+		msg = "Schedule change (dry run)"
+	}
+	for _, c := range changes {
+		svc.logger.InfoCtx(ctx, msg, logger.Any("schedule_id", c.ID), logger.Any("change", c.String()))
+	}
+	return changes, nil
+}
+
+// schedulesComponentRunner reconciles the schedules once on start
+type schedulesComponentRunner struct {
+	svc *Service
+}
+
+func (c *schedulesComponentRunner) Name() string {
+	return schedulesComponent
+}
+
+func (c *schedulesComponentRunner) Start(ctx context.Context) error {
+	_, err := c.svc.ReconcileSchedules(ctx, c.svc.cfg.GetBool("temporal.schedules.dryRun"))
+	return err
+}
+
+func (c *schedulesComponentRunner) Stop(_ context.Context) error {
+	return nil
+}
@@ -4,53 +4,77 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
-	"net"
 	"net/http"
 	"sync"
 	"time"
 
 	iaws "github.com/ConradKurth/gokit/aws"
 	"github.com/ConradKurth/gokit/config"
+	"github.com/ConradKurth/gokit/grpcclient"
+	"github.com/ConradKurth/gokit/health"
 	"github.com/ConradKurth/gokit/instrument"
 	"github.com/ConradKurth/gokit/logger"
-	sentryMiddleware "github.com/ConradKurth/gokit/middleware/sentry"
-	"github.com/ConradKurth/gokit/middleware/ssl"
 	"github.com/ConradKurth/gokit/secrets"
-	"github.com/andybalholm/brotli"
+	"github.com/ConradKurth/gokit/temporalcodec"
+	"github.com/ConradKurth/gokit/temporalschedule"
+	"github.com/ConradKurth/gokit/tlsconfig"
 	"github.com/getsentry/sentry-go"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
-	"github.com/riandyrn/otelchi"
-	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/worker"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 )
 
 const (
 	brotliCompressionLevel = 4
 	shutdownTimeout        = time.Second * 5
+	defaultDrainTimeout    = time.Second * 20
+	sentryFlushTimeout     = time.Second * 2
 )
 
 // Service implements common service functionalities for all services.
 type Service struct {
-	cfg         *config.Config
-	serviceName string
-	logger      logger.Logger
+	cfg           *config.Config
+	serviceName   string
+	logger        logger.Logger
+	sentryEnabled bool
+	health        *health.Registry
+	routerOpts    routerOptions
 
 	// tracer *trace.TracerProvider
 
 	temporalWorker worker.Worker
-	temporalClient client.Client
-
-	router     chi.Router
-	webserver  *http.Server
-	grpcServer *grpc.Server
+	// temporalWorkers holds the workers by task queue, including the default one
+	temporalWorkers map[string]worker.Worker
+	temporalClient  client.Client
+	// payloadCodecs are served by the codec server when payloads are encrypted
+	payloadCodecs []converter.PayloadCodec
+
+	router        chi.Router
+	webserver     *http.Server
+	privateRouter chi.Router
+	privateServer *http.Server
+	adminServer   *http.Server
+	grpcServer    *grpc.Server
+	grpcClients   *grpcclient.Registry
+
+	lock       sync.Mutex
+	components []*registeredComponent
+	ordered    []*registeredComponent
+	running    bool
+	cancelRun  context.CancelFunc
+	stopped    bool
+	schedules  []temporalschedule.Schedule
+	stopOnce   sync.Once
+	stopErr    error
 }
 
+// CronRegister creates cron workflows imperatively.
+//
+// Deprecated: declare schedules with RegisterSchedules, they are reconciled on start.
 type CronRegister interface {
 	InitCron() error
 }
@@ -70,6 +94,13 @@ func New(ctx context.Context, configPath, sentryDSN string, opts ...func(*option
 		o(&opt)
 	}
 
+	if opt.sentryDSN != "" {
+		sentryDSN = opt.sentryDSN
+	}
+	if err := opt.router.validate(); err != nil {
+		return nil, err
+	}
+
 	cfg := config.LoadConfig(config.WithPath(configPath))
 
 	var err error
@@ -78,10 +109,22 @@ func New(ctx context.Context, configPath, sentryDSN string, opts ...func(*option
 	}
 
 	svc := &Service{
-		cfg:         cfg,
-		serviceName: cfg.GetString("serviceName"),
-		logger:      logger.NewV2(cfg),
+		cfg:           cfg,
+		serviceName:   cfg.GetString("serviceName"),
+		logger:        logger.NewV2(cfg),
+		health:        health.New(),
+		sentryEnabled: opt.sentryEnabled,
+		routerOpts:    opt.router,
 	}
+	svc.grpcClients = grpcclient.NewRegistry(cfg, append([]grpcclient.Option{
+		grpcclient.WithCredentials(func(name string) (credentials.TransportCredentials, error) {
+			path := "grpc.clients." + name + ".tls"
+			if !cfg.GetBool(path + ".enabled") {
+				path = "grpc.client.tls"
+			}
+			return svc.grpcClientCredentials(cfg, path)
+		}),
+	}, opt.grpcClientOptions...)...)
 
 	if opt.sentryEnabled {
 		if err = sentry.Init(sentry.ClientOptions{
@@ -105,50 +148,134 @@ func New(ctx context.Context, configPath, sentryDSN string, opts ...func(*option
 	// }
 
 	if opt.temporalService {
-		svc.temporalClient, err = instrument.NewTemporalClient(ctx, cfg, svc.serviceName)
+		svc.temporalClient, err = instrument.NewTemporalClient(ctx, cfg, svc.serviceName,
+			instrument.WithContextFields(opt.temporalContextFields...),
+			instrument.WithTemporalLogger(svc.logger))
 		if err != nil {
 			return nil, fmt.Errorf("initializing temporal: %w", err)
 		}
-		svc.temporalWorker = worker.New(svc.temporalClient, cfg.GetString("temporal.taskQueue"), worker.Options{})
+		if svc.payloadCodecs, err = payloadCodecs(cfg); err != nil {
+			return nil, fmt.Errorf("initializing payload codecs: %w", err)
+		}
+		// the cache is shared by all workers of the process and has to be sized before they are created
+		if size := cfg.GetInt("temporal.worker.stickyCacheSize"); size != 0 {
+			worker.SetStickyWorkflowCacheSize(size)
+		}
+		svc.temporalWorkers = map[string]worker.Worker{}
+		if svc.temporalWorker, err = svc.worker(cfg.GetString("temporal.taskQueue")); err != nil {
+			return nil, err
+		}
+		svc.health.Register("temporal", health.Temporal(svc.temporalClient))
+	}
+
+	if opt.multiplex && !(opt.grpcService && opt.httpService) {
+		return nil, errors.New("multiplexing requires the grpc and http service")
 	}
 
 	if opt.grpcService {
+		unary, stream, err := svc.grpcInterceptors(cfg, opt.grpcInterceptors)
+		if err != nil {
+			return nil, fmt.Errorf("initializing grpc interceptors: %w", err)
+		}
+		creds, err := svc.grpcServerCredentials(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("initializing grpc credentials: %w", err)
+		}
 		svc.grpcServer = grpc.NewServer(
-			grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()),
-			grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()),
-			grpc.Creds(insecure.NewCredentials()),
+			grpc.ChainStreamInterceptor(stream...),
+			grpc.ChainUnaryInterceptor(unary...),
+			grpc.Creds(creds),
+			// clients may opt in to keepalive pings, see grpcclient
+			grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+				MinTime:             10 * time.Second,
+				PermitWithoutStream: true,
+			}),
 		)
+		svc.health.RegisterGRPC(svc.grpcServer)
+
+		var c Component = newGRPCComponent(svc.grpcServer, cfg.GetString("grpc.host"))
+		if opt.multiplex {
+			c = newMultiplexedGRPCComponent(svc.grpcServer)
+		}
+		if err = svc.registerServer(c); err != nil {
+			return nil, err
+		}
 	}
 
 	if opt.httpService {
-		svc.router = svc.initializeRouter(cfg)
+		if svc.router, err = svc.initializeRouter(cfg); err != nil {
+			return nil, fmt.Errorf("initializing router: %w", err)
+		}
+		// registered before the webserver so it is stopped after it
+		if opt.gateway != nil {
+			if !opt.grpcService {
+				return nil, errors.New("the grpc gateway requires the grpc service")
+			}
+			if err = svc.initializeGateway(ctx, opt.gateway); err != nil {
+				return nil, fmt.Errorf("initializing grpc gateway: %w", err)
+			}
+		}
 		svc.webserver = &http.Server{
 			Addr:    fmt.Sprintf(":%d", cfg.GetInt("api.port")),
 			Handler: svc.router,
 		}
+		if opt.multiplex {
+			if err = svc.multiplexServer(cfg, svc.webserver); err != nil {
+				return nil, fmt.Errorf("initializing multiplexed server: %w", err)
+			}
+		}
+		if err = svc.registerServer(newHTTPComponent(httpServerComponent, svc.webserver)); err != nil {
+			return nil, err
+		}
+
+		// internal routes get their own listener so they can never be reached through the public ingress
+		if port := cfg.GetInt("api.private.port"); port != 0 {
+			if svc.privateRouter, err = svc.initializePrivateRouter(cfg); err != nil {
+				return nil, fmt.Errorf("initializing private router: %w", err)
+			}
+			svc.privateServer = &http.Server{
+				Addr:    fmt.Sprintf(":%d", port),
+				Handler: svc.privateRouter,
+			}
+			if o := svc.tlsOptions(cfg, "api.private.tls"); o.Enabled {
+				if svc.privateServer.TLSConfig, err = tlsconfig.NewServerConfig(o); err != nil {
+					return nil, fmt.Errorf("initializing private tls: %w", err)
+				}
+			}
+			if err = svc.registerServer(newHTTPComponent(privateServerComponent, svc.privateServer)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if opt.adminService {
+		svc.adminServer = &http.Server{
+			Addr:    fmt.Sprintf(":%d", cfg.GetInt("admin.port")),
+			Handler: svc.initializeAdminRouter(),
+		}
+		if err = svc.registerServer(newHTTPComponent(adminServerComponent, svc.adminServer)); err != nil {
+			return nil, err
+		}
 	}
 
 	return svc, nil
 }
 
-var grpcClients = make(map[string]*grpc.ClientConn)
-var gprcClientLock sync.Mutex
+// GRPCClient returns the connection to the upstream configured under `grpc.clients.<name>`.
+// Connections are shared by the service and closed on shutdown.
+func (svc *Service) GRPCClient(name string) (*grpc.ClientConn, error) {
+	return svc.grpcClients.Get(name)
+}
 
-// GetGRPCClient will create a proper grpc client
+// GetGRPCClient will create a proper grpc client for the host, or the upstream when the
+// host is the name of one.
+//
+// Deprecated: configure the upstream under `grpc.clients` and use GRPCClient instead.
 func (svc *Service) GetGRPCClient(host string) (*grpc.ClientConn, error) {
-	gprcClientLock.Lock()
-	defer gprcClientLock.Unlock()
-
-	if c, ok := grpcClients[host]; ok {
-		return c, nil
+	if svc.cfg.GetString("grpc.clients."+host+".target") != "" {
+		return svc.grpcClients.Get(host)
 	}
-
-	c, err := grpc.Dial(host,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()),
-		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()))
-	grpcClients[host] = c
-	return c, err
+	return svc.grpcClients.Dial(host)
 }
 
 // Config returns the config of the service.
@@ -166,64 +293,45 @@ func (svc *Service) Temporal() client.Client {
 	return svc.temporalClient
 }
 
+// Health returns the health registry of the service. Register checks for
+// dependencies here to have them reported by the liveness and readiness probes.
+func (svc *Service) Health() *health.Registry {
+	return svc.health
+}
+
 // GRPC return the grpc server
 func (svc *Service) GRPC() *grpc.Server {
 	return svc.grpcServer
 }
 
-// Router returns the http router. This allows the caller to register custom routes.
+// Router returns the public http router. This allows the caller to register custom routes.
 func (svc *Service) Router() chi.Router {
 	return svc.router
 }
 
-// initializeRouter returns a router with standard health and middleware configured.
-func (svc *Service) initializeRouter(cfg *config.Config) chi.Router {
-	router := chi.NewRouter()
-	router.Use(middleware.Heartbeat("/healthz"))
-	router.Use(ssl.NewMiddleware(config.IsLocal()))
-
-	cors := cors.New(cors.Options{
-		AllowedOrigins:   cfg.GetStringSlice("cors.hosts"),
-		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	})
-
-	router.Use(cors.Handler)
-	// sample anything below
-	router.Use(otelchi.Middleware(svc.serviceName))
-	router.Use(middleware.RealIP)
-	router.Use(middleware.RequestID)
-	compression := middleware.NewCompressor(brotliCompressionLevel)
-	compression.SetEncoder("br", func(w io.Writer, level int) io.Writer {
-		return brotli.NewWriterLevel(w, level)
-	})
-	router.Use(compression.Handler)
-	router.Use(sentryMiddleware.NewMiddleware(cfg))
-	return router
-}
-
-// RegisterRoutes registers http routes with the webserver router.
-func (svc *Service) RegisterRoutes(routers []RouteRegistration, middlewares ...func(http.Handler) http.Handler) {
-	for _, route := range routers {
-		route.RegisterRoutes(svc.router, middlewares...)
-	}
-}
-
-// RegisterPublicRoutes registers http routes with the webserver router.
-func (svc *Service) RegisterPublicRoutes(routers []RouteRegistration, middlewares ...func(http.Handler) http.Handler) {
-
-	for _, route := range routers {
-		route.RegisterPublicRoutes(svc.router, middlewares...)
+// PrivateRouter returns the http router for internal routes. Unless `api.private.port` is
+// configured this is the same router as the public one.
+func (svc *Service) PrivateRouter() chi.Router {
+	if svc.privateRouter != nil {
+		return svc.privateRouter
 	}
+	return svc.router
 }
 
-// RegisterWithWorker registers types with the temporal worker.
-func (svc *Service) RegisterWithWorker(registrations []WorkerRegistration) {
+// RegisterWithWorker registers types with the temporal worker. Registrations implementing
+// TaskQueueSelector are registered with the worker of their task queue.
+func (svc *Service) RegisterWithWorker(registrations []WorkerRegistration) error {
 	for _, registration := range registrations {
-		registration.RegisterWithWorker(svc.temporalWorker)
+		w := svc.temporalWorker
+		if s, ok := registration.(TaskQueueSelector); ok && s.TaskQueue() != "" {
+			var err error
+			if w, err = svc.worker(s.TaskQueue()); err != nil {
+				return fmt.Errorf("creating worker for task queue %s: %w", s.TaskQueue(), err)
+			}
+		}
+		registration.RegisterWithWorker(w)
 	}
+	return nil
 }
 
 // RegisterWithCron registers crons with temporal
@@ -236,76 +344,85 @@ func (svc *Service) RegisterWithCron(registrations []CronRegister) error {
 	return nil
 }
 
-// Start starts all internal service connections and stops all servers.
-// The function blocks and should be run in a goroutine.
+// Start starts all registered components, including the temporal worker and the grpc and http servers.
+// The function blocks until all components have stopped and should be run in a goroutine.
+// If a component fails, all other components are stopped and the first error is returned.
 func (svc *Service) Start(ctx context.Context) error {
-	if svc.temporalWorker != nil {
-		svc.logger.InfoCtx(ctx, "Starting the temporal worker")
-		if err := svc.temporalWorker.Start(); err != nil {
-			return fmt.Errorf("starting temporal worker: %w", err)
-		}
-	}
+	return svc.runComponents(ctx)
+}
 
-	if svc.grpcServer != nil {
-		net, err := net.Listen("tcp", svc.cfg.GetString("grpc.host"))
-		if err != nil {
-			return fmt.Errorf("creating listener socket: %w", err)
-		}
-		go func() {
-			svc.logger.InfoCtx(ctx, "Starting the grpc server")
-			if err := svc.grpcServer.Serve(net); err != nil {
-				svc.Logger().ErrorCtx(ctx, "Error closing the grpc server", logger.ErrField(err))
-			}
-		}()
+// getDrainTimeout returns the budget in-flight work has to finish during shutdown.
+func getDrainTimeout(cfg *config.Config) time.Duration {
+	if secs := cfg.GetInt("shutdown.drainTimeoutSecs"); secs > 0 {
+		return time.Duration(secs) * time.Second
 	}
+	return defaultDrainTimeout
+}
 
-	if svc.webserver != nil {
-		svc.logger.InfoCtx(ctx, "Starting the webserver")
+// Shutdown drains the service and closes all internal service connections.
+// The readiness probe starts failing first, then after the pre-stop delay all components are
+// stopped in reverse start order within the drain budget, waiting for in-flight requests and
+// activities. Components still running after the budget are force-closed. Temporal workers
+// have their own stop timeout instead, the temporal client is closed once they stopped.
+// Finally the logger and sentry are flushed.
+func (svc *Service) Shutdown(ctx context.Context) error {
+	preStopDelay := time.Duration(svc.cfg.GetInt("shutdown.preStopDelaySecs")) * time.Second
+	drainTimeout := getDrainTimeout(svc.cfg)
 
-		if err := svc.webserver.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			return fmt.Errorf("starting webserver: %w", err)
+	svc.logger.InfoCtx(ctx, "Shutdown: failing readiness")
+	svc.health.Drain()
+
+	if preStopDelay > 0 {
+		svc.logger.InfoCtx(ctx, "Shutdown: waiting for the pre-stop delay", logger.Any("delay_secs", preStopDelay.Seconds()))
+		select {
+		case <-time.After(preStopDelay):
+		case <-ctx.Done():
 		}
 	}
 
-	return nil
-}
-
-// Shutdown closes all internal service connections and stops all servers.
-// It waits for all worker functions to finish.
-func (svc *Service) Shutdown(ctx context.Context) error {
-	var allErrs error
+	svc.logger.InfoCtx(ctx, "Shutdown: draining in-flight work", logger.Any("timeout_secs", drainTimeout.Seconds()))
+	drainCtx, cancel := context.WithTimeout(ctx, drainTimeout)
+	defer cancel()
 
-	if svc.temporalWorker != nil {
-		svc.temporalWorker.Stop()
+	allErrs := svc.stopComponents(drainCtx)
+	if drainCtx.Err() != nil {
+		svc.logger.WarnCtx(ctx, "Shutdown: drain budget exceeded, force closed remaining work")
 	}
+
+	svc.logger.InfoCtx(ctx, "Shutdown: closing connections")
 	if svc.temporalClient != nil {
+		// activities of stopping workers still complete and heartbeat through the client
+		svc.waitForWorkers(ctx)
 		svc.temporalClient.Close()
 	}
 
-	for _, conn := range grpcClients {
-		if err := conn.Close(); err != nil {
+	if svc.grpcClients != nil {
+		if err := svc.grpcClients.Close(); err != nil {
 			allErrs = errors.Join(allErrs, err)
 		}
 	}
 
-	if svc.grpcServer != nil {
-		svc.grpcServer.GracefulStop()
-	}
-
 	// if svc.tracer != nil {
 	// 	if err := svc.tracer.Shutdown(ctx); err != nil {
 	// 		errs = multierror.Append(errs, err)
 	// 	}
 	// }
 
-	if svc.webserver != nil {
-		timeout, cancel := context.WithTimeout(ctx, shutdownTimeout)
-		defer cancel()
-
-		if err := svc.webserver.Shutdown(timeout); err != nil {
-			allErrs = errors.Join(allErrs, err)
-		}
+	svc.logger.InfoCtx(ctx, "Shutdown: flushing logs and events")
+	if svc.sentryEnabled {
+		sentry.Flush(sentryFlushTimeout)
 	}
+	// syncing stdout returns an error on some platforms, there is nothing left to report it to
+	_ = svc.logger.Close()
 
 	return allErrs
 }
+
+// payloadCodecs returns the codecs of the temporal data converter, nil when payloads are not encrypted
+func payloadCodecs(cfg *config.Config) ([]converter.PayloadCodec, error) {
+	o, ok, err := temporalcodec.FromConfig(cfg)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return temporalcodec.NewCodecs(o)
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/ConradKurth/gokit/instrument"
+	"github.com/ConradKurth/gokit/logger"
+	loggerMiddleware "github.com/ConradKurth/gokit/middleware/logger"
+	"github.com/ConradKurth/gokit/middleware/recovery"
+	sentryMiddleware "github.com/ConradKurth/gokit/middleware/sentry"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/worker"
+)
+
+// TaskQueueSelector can be implemented by a WorkerRegistration to run on its own task queue
+// instead of `temporal.taskQueue`. The worker of the queue is created on first use.
+type TaskQueueSelector interface {
+	TaskQueue() string
+}
+
+// readBuildInfo is replaced in tests
+var readBuildInfo = debug.ReadBuildInfo
+
+// workerOptions returns the options of the worker of the task queue. They are read from
+// `temporal.worker` and can be overridden per task queue under `temporal.workers.<taskQueue>`.
+func (svc *Service) workerOptions(cfg *config.Config, taskQueue string) (worker.Options, error) {
+	getString := func(key string) string {
+		if v := cfg.GetString(fmt.Sprintf("temporal.workers.%s.%s", taskQueue, key)); v != "" {
+			return v
+		}
+		return cfg.GetString("temporal.worker." + key)
+	}
+	getBool := func(key string) bool {
+		k := fmt.Sprintf("temporal.workers.%s.%s", taskQueue, key)
+		return cfg.GetBoolDefault(k, cfg.GetBool("temporal.worker."+key))
+	}
+	getInt := func(key string) int {
+		if v := cfg.GetInt(fmt.Sprintf("temporal.workers.%s.%s", taskQueue, key)); v != 0 {
+			return v
+		}
+		return cfg.GetInt("temporal.worker." + key)
+	}
+	getFloat := func(key string) float64 {
+		if v := cfg.GetFloat64(fmt.Sprintf("temporal.workers.%s.%s", taskQueue, key)); v != 0 {
+			return v
+		}
+		return cfg.GetFloat64("temporal.worker." + key)
+	}
+
+	// let running activities finish while draining
+	stopTimeout := getDrainTimeout(cfg)
+	if secs := getInt("stopTimeoutSecs"); secs != 0 {
+		stopTimeout = time.Duration(secs) * time.Second
+	}
+
+	// with versioning the server only sends tasks of workflows started on a compatible build
+	buildID := getString("buildId")
+	if buildID == "" {
+		buildID = buildIDFromBuildInfo()
+	}
+	versioning := getBool("useBuildIdForVersioning")
+	if versioning && buildID == "" {
+		return worker.Options{}, errors.New("temporal.worker.useBuildIdForVersioning requires a build id")
+	}
+
+	return worker.Options{
+		BuildID:                                 buildID,
+		UseBuildIDForVersioning:                 versioning,
+		MaxConcurrentActivityTaskPollers:        getInt("maxConcurrentActivityTaskPollers"),
+		MaxConcurrentWorkflowTaskPollers:        getInt("maxConcurrentWorkflowTaskPollers"),
+		MaxConcurrentActivityExecutionSize:      getInt("maxConcurrentActivityExecutionSize"),
+		MaxConcurrentWorkflowTaskExecutionSize:  getInt("maxConcurrentWorkflowTaskExecutionSize"),
+		MaxConcurrentLocalActivityExecutionSize: getInt("maxConcurrentLocalActivityExecutionSize"),
+		WorkerActivitiesPerSecond:               getFloat("workerActivitiesPerSecond"),
+		TaskQueueActivitiesPerSecond:            getFloat("taskQueueActivitiesPerSecond"),
+		WorkerStopTimeout:                       stopTimeout,
+		Interceptors:                            WorkerInterceptors(svc.logger),
+	}, nil
+}
+
+// WorkerInterceptors returns the interceptors of the temporal workers of the service, e.g. to
+// replay histories like the workers run them
+func WorkerInterceptors(l logger.Logger) []interceptor.WorkerInterceptor {
+	// recovery runs innermost so the other interceptors see recovered panics as errors
+	return []interceptor.WorkerInterceptor{
+		instrument.NewTemporalMetricsInterceptor(),
+		loggerMiddleware.NewTemporalInterceptor(l),
+		sentryMiddleware.NewTemporalInterceptor(),
+		recovery.NewTemporalInterceptor(l),
+	}
+}
+
+// buildIDFromBuildInfo returns the vcs revision the binary was built from, or the version of
+// the main module when it was installed with a version
+func buildIDFromBuildInfo() string {
+	info, ok := readBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if revision != "" {
+		if modified == "true" {
+			return revision + "-dirty"
+		}
+		return revision
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	return ""
+}
+
+// worker returns the worker of the task queue, it is created and registered as a component
+// on first use.
+func (svc *Service) worker(taskQueue string) (worker.Worker, error) {
+	svc.lock.Lock()
+	w, ok := svc.temporalWorkers[taskQueue]
+	svc.lock.Unlock()
+	if ok {
+		return w, nil
+	}
+
+	opts, err := svc.workerOptions(svc.cfg, taskQueue)
+	if err != nil {
+		return nil, fmt.Errorf("worker of %s: %w", taskQueue, err)
+	}
+	w = worker.New(svc.temporalClient, taskQueue, opts)
+	name := temporalWorkerComponent
+	if taskQueue != svc.cfg.GetString("temporal.taskQueue") {
+		name = temporalWorkerComponent + "-" + taskQueue
+	}
+	// the worker waits for activities up to its stop timeout, then needs a moment to close
+	err = svc.RegisterComponent(newTemporalComponent(name, w),
+		StopTimeout(opts.WorkerStopTimeout+shutdownTimeout), ownStopBudget())
+	if err != nil {
+		return nil, err
+	}
+
+	svc.lock.Lock()
+	svc.temporalWorkers[taskQueue] = w
+	svc.lock.Unlock()
+	return w, nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"runtime/debug"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+func Test_WorkerOptions(t *testing.T) {
+	svc := newTestService(map[string]interface{}{
+		"temporal": map[string]interface{}{
+			"worker": map[string]interface{}{
+				"maxConcurrentActivityTaskPollers":   4,
+				"maxConcurrentActivityExecutionSize": 100,
+				"workerActivitiesPerSecond":          50.5,
+				"stopTimeoutSecs":                    30,
+			},
+			"workers": map[string]interface{}{
+				"reports": map[string]interface{}{
+					"maxConcurrentActivityExecutionSize": 5,
+					"taskQueueActivitiesPerSecond":       2,
+				},
+			},
+		},
+	})
+
+	o, err := svc.workerOptions(svc.cfg, "default")
+	require.NoError(t, err)
+	assert.Equal(t, 4, o.MaxConcurrentActivityTaskPollers)
+	assert.Equal(t, 100, o.MaxConcurrentActivityExecutionSize)
+	assert.Equal(t, 50.5, o.WorkerActivitiesPerSecond)
+	assert.Equal(t, float64(0), o.TaskQueueActivitiesPerSecond)
+	assert.Equal(t, 30*time.Second, o.WorkerStopTimeout)
+	assert.Len(t, o.Interceptors, 4)
+
+	o, err = svc.workerOptions(svc.cfg, "reports")
+	require.NoError(t, err)
+	assert.Equal(t, 4, o.MaxConcurrentActivityTaskPollers)
+	assert.Equal(t, 5, o.MaxConcurrentActivityExecutionSize)
+	assert.Equal(t, float64(2), o.TaskQueueActivitiesPerSecond)
+
+	// the drain timeout is the default stop timeout
+	svc = newTestService(nil)
+	o, err = svc.workerOptions(svc.cfg, "default")
+	require.NoError(t, err)
+	assert.Equal(t, defaultDrainTimeout, o.WorkerStopTimeout)
+}
+
+func Test_WorkerOptions_BuildID(t *testing.T) {
+	defer func(r func() (*debug.BuildInfo, bool)) { readBuildInfo = r }(readBuildInfo)
+
+	tests := []struct {
+		name       string
+		worker     map[string]interface{}
+		settings   []debug.BuildSetting
+		version    string
+		buildID    string
+		versioning bool
+		err        string
+	}{
+		{
+			name:     "revision",
+			settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "4f2a9c1"}},
+			version:  "(devel)",
+			buildID:  "4f2a9c1",
+		},
+		{
+			name:     "modified revision",
+			settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "4f2a9c1"}, {Key: "vcs.modified", Value: "true"}},
+			buildID:  "4f2a9c1-dirty",
+		},
+		{
+			name:    "module version",
+			version: "v1.2.3",
+			buildID: "v1.2.3",
+		},
+		{
+			name:       "config",
+			worker:     map[string]interface{}{"buildId": "release-7", "useBuildIdForVersioning": true},
+			settings:   []debug.BuildSetting{{Key: "vcs.revision", Value: "4f2a9c1"}},
+			buildID:    "release-7",
+			versioning: true,
+		},
+		{
+			name:    "no build id",
+			version: "(devel)",
+		},
+		{
+			name:    "versioning without build id",
+			worker:  map[string]interface{}{"useBuildIdForVersioning": true},
+			version: "(devel)",
+			err:     "temporal.worker.useBuildIdForVersioning requires a build id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readBuildInfo = func() (*debug.BuildInfo, bool) {
+				return &debug.BuildInfo{Main: debug.Module{Version: tt.version}, Settings: tt.settings}, true
+			}
+			svc := newTestService(map[string]interface{}{
+				"temporal": map[string]interface{}{"worker": tt.worker},
+			})
+
+			o, err := svc.workerOptions(svc.cfg, "default")
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.buildID, o.BuildID)
+			assert.Equal(t, tt.versioning, o.UseBuildIDForVersioning)
+		})
+	}
+}
+
+type blockingWorker struct {
+	worker.Worker
+	release chan struct{}
+}
+
+func (w *blockingWorker) Stop() {
+	<-w.release
+}
+
+func Test_TemporalComponent_Stop(t *testing.T) {
+	w := &blockingWorker{release: make(chan struct{})}
+	c := newTemporalComponent(temporalWorkerComponent, w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.Stop(ctx), context.DeadlineExceeded)
+
+	// the worker keeps stopping in the background
+	close(w.release)
+	require.NoError(t, c.Stop(context.Background()))
+	select {
+	case <-c.stopped:
+	default:
+		t.Fatal("worker was not stopped")
+	}
+}
+
+type startRecorder struct {
+	worker.Worker
+	started bool
+}
+
+func (w *startRecorder) Start() error {
+	w.started = true
+	return nil
+}
+
+func (w *startRecorder) Stop() {}
+
+func Test_TemporalComponent_StopBeforeStart(t *testing.T) {
+	w := &startRecorder{}
+	c := newTemporalComponent(temporalWorkerComponent, w)
+
+	require.NoError(t, c.Stop(context.Background()))
+	require.NoError(t, c.Start(context.Background()))
+	assert.False(t, w.started)
+}
+
+func Test_StopComponents_WorkerStopBudget(t *testing.T) {
+	svc := newTestService(nil)
+	w := &blockingWorker{release: make(chan struct{})}
+	defer close(w.release)
+	require.NoError(t, svc.RegisterComponent(newTemporalComponent(temporalWorkerComponent, w),
+		StopTimeout(10*time.Millisecond), ownStopBudget()))
+
+	// the drain budget is used up, the worker still gets its stop timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := svc.stopComponents(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, context.Canceled)
+}
+
+type closeRecorder struct {
+	client.Client
+	closed func()
+}
+
+func (c *closeRecorder) Close() {
+	c.closed()
+}
+
+func Test_Shutdown_WaitsForWorker(t *testing.T) {
+	svc := newTestService(nil)
+	w := &blockingWorker{release: make(chan struct{})}
+	require.NoError(t, svc.RegisterComponent(newTemporalComponent(temporalWorkerComponent, w),
+		StopTimeout(10*time.Millisecond), ownStopBudget()))
+
+	var released atomic.Bool
+	closedAfterRelease := false
+	svc.temporalClient = &closeRecorder{closed: func() {
+		closedAfterRelease = released.Load()
+	}}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		released.Store(true)
+		close(w.release)
+	}()
+
+	// the worker keeps stopping after its stop timeout, the client is closed after it
+	assert.ErrorIs(t, svc.Shutdown(context.Background()), context.DeadlineExceeded)
+	assert.True(t, closedAfterRelease)
+}
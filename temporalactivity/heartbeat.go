@@ -0,0 +1,171 @@
+package temporalactivity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ConradKurth/gokit/limitgroup"
+	"go.temporal.io/sdk/activity"
+)
+
+const defaultInterval = 10 * time.Second
+
+// ErrWorkerStopping is the cause of the context cancellation when the worker shuts down. The
+// progress is recorded, so the retry on another worker continues from it.
+var ErrWorkerStopping = errors.New("worker is stopping")
+
+type options struct {
+	interval time.Duration
+}
+
+// WithInterval sets how often the activity heartbeats. It defaults to half of the heartbeat
+// timeout of the activity, or 10 seconds without one. It must be positive.
+func WithInterval(d time.Duration) func(*options) {
+	return func(o *options) {
+		o.interval = d
+	}
+}
+
+// Progress is the checkpoint of an activity. It is sent with every heartbeat and restored
+// from the details of the last heartbeat when the activity is retried. It is safe to update
+// from several goroutines.
+type Progress[T any] struct {
+	lock  sync.Mutex
+	value T
+}
+
+// Get returns the current progress
+func (p *Progress[T]) Get() T {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.value
+}
+
+// Set replaces the progress
+func (p *Progress[T]) Set(value T) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.value = value
+}
+
+// Update changes the progress in place
+func (p *Progress[T]) Update(fn func(value *T)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	fn(&p.value)
+}
+
+// Run calls fn while heartbeating the progress in the background. The progress starts from
+// the last heartbeat of a previous attempt. The context of fn is cancelled when the activity
+// is cancelled through a heartbeat or the worker stops, the progress is heartbeated once more
+// before Run returns so a retry continues where this attempt stopped.
+func Run[T any](ctx context.Context, fn func(ctx context.Context, progress *Progress[T]) error, opts ...func(*options)) error {
+	o := options{interval: defaultInterval}
+	if timeout := activity.GetInfo(ctx).HeartbeatTimeout; timeout > 0 {
+		o.interval = timeout / 2
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.interval <= 0 {
+		return fmt.Errorf("heartbeat interval must be positive, got %s", o.interval)
+	}
+
+	progress := &Progress[T]{}
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &progress.value); err != nil {
+			return fmt.Errorf("restoring progress: %w", err)
+		}
+	}
+
+	// the sdk cancels the activity context when a heartbeat reports the cancellation
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				activity.RecordHeartbeat(ctx, progress.Get())
+			case <-activity.GetWorkerStopChannel(ctx):
+				cancel(ErrWorkerStopping)
+				return
+			case <-runCtx.Done():
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := fn(runCtx, progress)
+	close(done)
+	wg.Wait()
+
+	// the last checkpoint is only needed by a retry
+	if err != nil {
+		activity.RecordHeartbeat(ctx, progress.Get())
+	}
+	return err
+}
+
+// Chunks is the progress of work split into numbered chunks
+type Chunks struct {
+	Done map[int]bool
+}
+
+// ForEachChunk calls fn for every chunk from 0 to count that is not done, at most limit at a
+// time. Finished chunks are marked done in the progress, so a retry only runs the rest. It
+// stops starting chunks once one failed or the context is cancelled.
+func ForEachChunk(ctx context.Context, progress *Progress[Chunks], count, limit int, fn func(ctx context.Context, chunk int) error) error {
+	if limit <= 0 {
+		return fmt.Errorf("chunk limit must be positive, got %d", limit)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	g := limitgroup.New(limit)
+	for chunk := 0; chunk < count; chunk++ {
+		done := false
+		progress.Update(func(c *Chunks) {
+			done = c.Done[chunk]
+		})
+		if done {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		g.Go(ctx, func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err := fn(ctx, chunk); err != nil {
+				cancel()
+				return fmt.Errorf("chunk %d: %w", chunk, err)
+			}
+			progress.Update(func(c *Chunks) {
+				if c.Done == nil {
+					c.Done = map[int]bool{}
+				}
+				c.Done[chunk] = true
+			})
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	return context.Cause(ctx)
+}
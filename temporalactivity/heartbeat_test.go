@@ -0,0 +1,191 @@
+package temporalactivity
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+type importProgress struct {
+	Row int
+}
+
+func importActivity(ctx context.Context, rows int) (int, error) {
+	var processed int
+	err := Run(ctx, func(ctx context.Context, p *Progress[importProgress]) error {
+		for row := p.Get().Row; row < rows; row++ {
+			if row == 3 && !activity.HasHeartbeatDetails(ctx) {
+				return errors.New("broken row")
+			}
+			processed++
+			p.Set(importProgress{Row: row + 1})
+		}
+		return nil
+	})
+	return processed, err
+}
+
+func TestRun(t *testing.T) {
+	var s testsuite.WorkflowTestSuite
+
+	// the first attempt fails and records its progress
+	env := s.NewTestActivityEnvironment()
+	var details []importProgress
+	var lock sync.Mutex
+	env.SetOnActivityHeartbeatListener(func(_ *activity.Info, d converter.EncodedValues) {
+		var p importProgress
+		require.NoError(t, d.Get(&p))
+		lock.Lock()
+		details = append(details, p)
+		lock.Unlock()
+	})
+	env.RegisterActivity(importActivity)
+	_, err := env.ExecuteActivity(importActivity, 5)
+	assert.ErrorContains(t, err, "broken row")
+	assert.Equal(t, []importProgress{{Row: 3}}, details)
+
+	// the retry continues from it
+	env = s.NewTestActivityEnvironment()
+	env.SetHeartbeatDetails(importProgress{Row: 3})
+	env.RegisterActivity(importActivity)
+	v, err := env.ExecuteActivity(importActivity, 5)
+	require.NoError(t, err)
+	var processed int
+	require.NoError(t, v.Get(&processed))
+	assert.Equal(t, 2, processed)
+}
+
+func TestRun_Heartbeats(t *testing.T) {
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestActivityEnvironment()
+
+	beats := make(chan int, 100)
+	env.SetOnActivityHeartbeatListener(func(_ *activity.Info, d converter.EncodedValues) {
+		var n int
+		require.NoError(t, d.Get(&n))
+		beats <- n
+	})
+
+	stop := make(chan struct{})
+	env.SetWorkerStopChannel(stop)
+	slow := func(ctx context.Context) error {
+		return Run(ctx, func(ctx context.Context, p *Progress[int]) error {
+			p.Set(7)
+			<-beats
+			close(stop)
+			<-ctx.Done()
+			return context.Cause(ctx)
+		}, WithInterval(time.Millisecond))
+	}
+	env.RegisterActivity(slow)
+
+	_, err := env.ExecuteActivity(slow)
+	assert.ErrorContains(t, err, ErrWorkerStopping.Error())
+	// the progress is recorded before returning
+	assert.Equal(t, 7, <-beats)
+}
+
+func TestRun_InvalidInterval(t *testing.T) {
+	tt := []struct {
+		Name             string
+		HeartbeatTimeout time.Duration
+		Opts             []func(*options)
+		Err              string
+	}{
+		{
+			Name: "zero interval",
+			Opts: []func(*options){WithInterval(0)},
+			Err:  "heartbeat interval must be positive, got 0s",
+		},
+		{
+			Name: "negative interval",
+			Opts: []func(*options){WithInterval(-time.Second)},
+			Err:  "heartbeat interval must be positive, got -1s",
+		},
+		{
+			Name:             "heartbeat timeout below 2ns",
+			HeartbeatTimeout: time.Nanosecond,
+			Err:              "heartbeat interval must be positive, got 0s",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			noop := func(ctx context.Context) error {
+				return Run(ctx, func(context.Context, *Progress[int]) error {
+					return nil
+				}, tc.Opts...)
+			}
+			wf := func(ctx workflow.Context) error {
+				ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+					StartToCloseTimeout: time.Minute,
+					HeartbeatTimeout:    tc.HeartbeatTimeout,
+					RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 1},
+				})
+				return workflow.ExecuteActivity(ctx, noop).Get(ctx, nil)
+			}
+
+			var s testsuite.WorkflowTestSuite
+			env := s.NewTestWorkflowEnvironment()
+			env.RegisterActivity(noop)
+			env.ExecuteWorkflow(wf)
+			assert.ErrorContains(t, env.GetWorkflowError(), tc.Err)
+		})
+	}
+}
+
+func TestForEachChunk(t *testing.T) {
+	ctx := context.Background()
+	p := &Progress[Chunks]{}
+	p.Set(Chunks{Done: map[int]bool{1: true}})
+
+	var lock sync.Mutex
+	var ran []int
+	err := ForEachChunk(ctx, p, 6, 2, func(_ context.Context, chunk int) error {
+		if chunk == 4 {
+			return errors.New("boom")
+		}
+		lock.Lock()
+		ran = append(ran, chunk)
+		lock.Unlock()
+		return nil
+	})
+	assert.ErrorContains(t, err, "chunk 4: boom")
+	assert.NotContains(t, ran, 1)
+	assert.False(t, p.Get().Done[4])
+	done := map[int]bool{}
+	p.Update(func(c *Chunks) {
+		for chunk := range c.Done {
+			done[chunk] = true
+		}
+	})
+
+	ran = nil
+	require.NoError(t, ForEachChunk(ctx, p, 6, 2, func(_ context.Context, chunk int) error {
+		lock.Lock()
+		ran = append(ran, chunk)
+		lock.Unlock()
+		return nil
+	}))
+	for _, chunk := range ran {
+		assert.False(t, done[chunk], "chunk %d ran twice", chunk)
+	}
+	assert.Len(t, p.Get().Done, 6)
+}
+
+func TestForEachChunk_InvalidLimit(t *testing.T) {
+	err := ForEachChunk(context.Background(), &Progress[Chunks]{}, 3, 0, func(context.Context, int) error {
+		return nil
+	})
+	assert.EqualError(t, err, "chunk limit must be positive, got 0")
+}
@@ -0,0 +1,178 @@
+package temporalcodec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ConradKurth/gokit/config"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// MetadataEncoding is the encoding of encrypted payloads
+	MetadataEncoding = "binary/encrypted"
+	// MetadataKeyID holds the id of the key a payload was encrypted with
+	MetadataKeyID = "encryption-key-id"
+	// MetadataCipher holds the cipher a payload was encrypted with
+	MetadataCipher = "encryption-cipher"
+
+	cipherAESGCM = "AES-GCM"
+)
+
+// Options configures the payload codecs
+type Options struct {
+	// Keys are the AES keys by id, they must be 16, 24 or 32 bytes long. Keys that were
+	// rotated out have to stay to decode older payloads.
+	Keys map[string][]byte
+	// ActiveKeyID is the id of the key new payloads are encrypted with
+	ActiveKeyID string
+	// Compress compresses payloads before they are encrypted when it makes them smaller
+	Compress bool
+}
+
+// FromConfig reads the options from `temporal.encryption`. The keys are base64 encoded under
+// `temporal.encryption.keys.<id>`, usually loaded from secrets. Key ids are case insensitive.
+// It returns false when encryption is not enabled.
+func FromConfig(c *config.Config) (Options, bool, error) {
+	if !c.GetBool("temporal.encryption.enabled") {
+		return Options{}, false, nil
+	}
+
+	o := Options{
+		Keys:        map[string][]byte{},
+		ActiveKeyID: strings.ToLower(c.GetString("temporal.encryption.activeKeyId")),
+		Compress:    c.GetBool("temporal.encryption.compress"),
+	}
+	for id, encoded := range c.GetStringMapString("temporal.encryption.keys") {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return Options{}, false, fmt.Errorf("decoding encryption key %q: %w", id, err)
+		}
+		o.Keys[strings.ToLower(id)] = key
+	}
+	return o, true, nil
+}
+
+// NewCodecs returns the codecs for the options in the order NewCodecDataConverter expects them
+func NewCodecs(o Options) ([]converter.PayloadCodec, error) {
+	enc, err := NewEncryptionCodec(o.Keys, o.ActiveKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	codecs := []converter.PayloadCodec{enc}
+	if o.Compress {
+		// encoding applies the codecs last to first, so payloads are compressed before encryption
+		codecs = append(codecs, converter.NewZlibCodec(converter.ZlibCodecOptions{}))
+	}
+	return codecs, nil
+}
+
+// NewDataConverter returns the default data converter wrapped with the codecs of the options
+func NewDataConverter(o Options) (converter.DataConverter, error) {
+	codecs, err := NewCodecs(o)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewCodecDataConverter(converter.GetDefaultDataConverter(), codecs...), nil
+}
+
+type encryptionCodec struct {
+	active string
+	aeads  map[string]cipher.AEAD
+}
+
+// NewEncryptionCodec returns a codec that encrypts payloads with AES-GCM using the active key.
+// Payloads are decrypted with the key they were encrypted with, unencrypted payloads are
+// passed through so encryption can be enabled on running workflows.
+func NewEncryptionCodec(keys map[string][]byte, activeKeyID string) (converter.PayloadCodec, error) {
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active encryption key %q is not configured", activeKeyID)
+	}
+
+	c := &encryptionCodec{
+		active: activeKeyID,
+		aeads:  make(map[string]cipher.AEAD, len(keys)),
+	}
+	for id, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", id, err)
+		}
+		c.aeads[id] = aead
+	}
+	return c, nil
+}
+
+// Encode will encrypt the payloads with the active key
+func (c *encryptionCodec) Encode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	aead := c.aeads[c.active]
+
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		plain, err := proto.Marshal(p)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling payload: %w", err)
+		}
+
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, fmt.Errorf("generating nonce: %w", err)
+		}
+
+		result[i] = &commonpb.Payload{
+			Metadata: map[string][]byte{
+				converter.MetadataEncoding: []byte(MetadataEncoding),
+				MetadataKeyID:              []byte(c.active),
+				MetadataCipher:             []byte(cipherAESGCM),
+			},
+			// the key id is authenticated so a payload can not be moved to another key
+			Data: aead.Seal(nonce, nonce, plain, []byte(c.active)),
+		}
+	}
+	return result, nil
+}
+
+// Decode will decrypt the payloads with the key they were encrypted with
+func (c *encryptionCodec) Decode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		if string(p.Metadata[converter.MetadataEncoding]) != MetadataEncoding {
+			result[i] = p
+			continue
+		}
+
+		keyID := string(p.Metadata[MetadataKeyID])
+		aead, ok := c.aeads[keyID]
+		if !ok {
+			return nil, fmt.Errorf("unknown encryption key %q", keyID)
+		}
+		if len(p.Data) < aead.NonceSize() {
+			return nil, errors.New("encrypted payload is too short")
+		}
+
+		nonce, sealed := p.Data[:aead.NonceSize()], p.Data[aead.NonceSize():]
+		plain, err := aead.Open(nil, nonce, sealed, []byte(keyID))
+		if err != nil {
+			return nil, fmt.Errorf("decrypting payload: %w", err)
+		}
+
+		result[i] = &commonpb.Payload{}
+		if err := proto.Unmarshal(plain, result[i]); err != nil {
+			return nil, fmt.Errorf("unmarshaling payload: %w", err)
+		}
+	}
+	return result, nil
+}
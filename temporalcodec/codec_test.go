@@ -0,0 +1,140 @@
+package temporalcodec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	oldKey = bytes.Repeat([]byte{1}, 32)
+	newKey = bytes.Repeat([]byte{2}, 16)
+)
+
+func TestEncryptionCodec(t *testing.T) {
+	old, err := NewDataConverter(Options{Keys: map[string][]byte{"k1": oldKey}, ActiveKeyID: "k1"})
+	require.NoError(t, err)
+
+	p, err := old.ToPayload("secret customer data")
+	require.NoError(t, err)
+	assert.Equal(t, MetadataEncoding, string(p.Metadata[converter.MetadataEncoding]))
+	assert.Equal(t, "k1", string(p.Metadata[MetadataKeyID]))
+	assert.NotContains(t, string(p.Data), "secret")
+
+	// after the rotation new payloads use the new key and old payloads can still be decoded
+	rotated, err := NewDataConverter(Options{
+		Keys:        map[string][]byte{"k1": oldKey, "k2": newKey},
+		ActiveKeyID: "k2",
+		Compress:    true,
+	})
+	require.NoError(t, err)
+
+	var out string
+	require.NoError(t, rotated.FromPayload(p, &out))
+	assert.Equal(t, "secret customer data", out)
+
+	p, err = rotated.ToPayload(strings.Repeat("a", 1024))
+	require.NoError(t, err)
+	assert.Equal(t, "k2", string(p.Metadata[MetadataKeyID]))
+	require.NoError(t, rotated.FromPayload(p, &out))
+	assert.Equal(t, strings.Repeat("a", 1024), out)
+
+	// payloads of a removed key can not be decoded
+	assert.ErrorContains(t, old.FromPayload(p, &out), `unknown encryption key "k2"`)
+
+	// unencrypted payloads are passed through
+	plain, err := converter.GetDefaultDataConverter().ToPayload("plain")
+	require.NoError(t, err)
+	require.NoError(t, rotated.FromPayload(plain, &out))
+	assert.Equal(t, "plain", out)
+}
+
+func TestEncryptionCodec_Tampered(t *testing.T) {
+	codec, err := NewEncryptionCodec(map[string][]byte{"k1": oldKey, "k2": newKey}, "k1")
+	require.NoError(t, err)
+
+	p, err := converter.GetDefaultDataConverter().ToPayload("value")
+	require.NoError(t, err)
+	encoded, err := codec.Encode([]*commonpb.Payload{p})
+	require.NoError(t, err)
+
+	// the key id is authenticated
+	encoded[0].Metadata[MetadataKeyID] = []byte("k2")
+	_, err = codec.Decode(encoded)
+	assert.ErrorContains(t, err, "decrypting payload")
+}
+
+func TestNewEncryptionCodec_Errors(t *testing.T) {
+	_, err := NewEncryptionCodec(map[string][]byte{"k1": oldKey}, "k2")
+	assert.EqualError(t, err, `active encryption key "k2" is not configured`)
+
+	_, err = NewEncryptionCodec(map[string][]byte{"k1": []byte("short")}, "k1")
+	assert.ErrorContains(t, err, `encryption key "k1"`)
+}
+
+func TestFromConfig(t *testing.T) {
+	c := config.LoadConfig(config.WithMap(map[string]interface{}{
+		"temporal": map[string]interface{}{
+			"encryption": map[string]interface{}{
+				"enabled":     true,
+				"activeKeyId": "K2",
+				"compress":    true,
+				"keys": map[string]interface{}{
+					"k1": base64.StdEncoding.EncodeToString(oldKey),
+					"k2": base64.StdEncoding.EncodeToString(newKey),
+				},
+			},
+		},
+	}))
+
+	o, ok, err := FromConfig(c)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, Options{
+		Keys:        map[string][]byte{"k1": oldKey, "k2": newKey},
+		ActiveKeyID: "k2",
+		Compress:    true,
+	}, o)
+
+	_, ok, err = FromConfig(config.LoadConfig(config.WithMap(map[string]interface{}{})))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestHandler(t *testing.T) {
+	codecs, err := NewCodecs(Options{Keys: map[string][]byte{"k1": oldKey}, ActiveKeyID: "k1"})
+	require.NoError(t, err)
+	h := NewHandler(codecs, []string{"https://temporal.example.com"})
+
+	p, err := converter.GetDefaultDataConverter().ToPayload("value")
+	require.NoError(t, err)
+	encoded, err := codecs[0].Encode([]*commonpb.Payload{p})
+	require.NoError(t, err)
+
+	body, err := protojson.Marshal(&commonpb.Payloads{Payloads: encoded})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/decode", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://temporal.example.com")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "https://temporal.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+
+	var decoded commonpb.Payloads
+	require.NoError(t, protojson.Unmarshal(rec.Body.Bytes(), &decoded))
+	var out string
+	require.NoError(t, json.Unmarshal(decoded.Payloads[0].Data, &out))
+	assert.Equal(t, "value", out)
+}
@@ -0,0 +1,26 @@
+package temporalcodec
+
+import (
+	"net/http"
+
+	"github.com/go-chi/cors"
+	"go.temporal.io/sdk/converter"
+)
+
+// NewHandler returns a codec server for the Temporal UI and CLI. It serves `/encode` and
+// `/decode` below the path it is mounted on. The UI calls it from the browser, so the origin
+// of the UI has to be allowed. The middlewares, e.g. auth, run after CORS, so preflight
+// requests of the browser are answered without credentials.
+func NewHandler(codecs []converter.PayloadCodec, origins []string, middlewares ...func(http.Handler) http.Handler) http.Handler {
+	c := cors.New(cors.Options{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{http.MethodPost, http.MethodOptions},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "X-Namespace"},
+		AllowCredentials: true,
+	})
+	h := converter.NewPayloadCodecHTTPHandler(codecs...)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return c.Handler(h)
+}
@@ -0,0 +1,240 @@
+package temporalctx
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ConradKurth/gokit/middleware/auth"
+	"github.com/ConradKurth/gokit/middleware/currencies"
+	"github.com/ConradKurth/gokit/middleware/region"
+	"github.com/ConradKurth/gokit/middleware/userinfo"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/workflow"
+	"golang.org/x/text/currency"
+)
+
+// HeaderKey is the temporal header holding the propagated values
+const HeaderKey = "gokit-context"
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "temporalctx-key-" + string(c)
+}
+
+// valuesKey holds the propagated values in workflow contexts
+const valuesKey = contextKey("values")
+
+// Keys of the default fields
+const (
+	KeyUserID    = "userID"
+	KeyGroups    = "groups"
+	KeyRegion    = "region"
+	KeyCurrency  = "currency"
+	KeyAdmin     = "admin"
+	KeyRequestID = "requestID"
+)
+
+// Field is a context value that is propagated to workflows and activities
+type Field struct {
+	Key string
+	// Get returns the value of the context and whether it is set
+	Get func(ctx context.Context) (string, bool)
+	// Set restores the value on the context of an activity
+	Set func(ctx context.Context, value string) (context.Context, error)
+}
+
+// DefaultFields returns the fields of the gokit middleware: the user id and groups, region,
+// currency, admin flag and request id.
+func DefaultFields() []Field {
+	return []Field{
+		{
+			Key: KeyUserID,
+			Get: func(ctx context.Context) (string, bool) {
+				id, err := userinfo.GetUserID(ctx)
+				return id, err == nil
+			},
+			Set: func(ctx context.Context, value string) (context.Context, error) {
+				return userinfo.SetUserID(ctx, value), nil
+			},
+		},
+		{
+			Key: KeyGroups,
+			Get: func(ctx context.Context) (string, bool) {
+				groups, err := userinfo.GetGroups(ctx)
+				return strings.Join(groups, ","), err == nil
+			},
+			Set: func(ctx context.Context, value string) (context.Context, error) {
+				if value == "" {
+					return userinfo.SetGroups(ctx, nil), nil
+				}
+				return userinfo.SetGroups(ctx, strings.Split(value, ",")), nil
+			},
+		},
+		{
+			Key: KeyRegion,
+			Get: func(ctx context.Context) (string, bool) {
+				r, err := region.GetRegion(ctx)
+				return r.String(), err == nil
+			},
+			Set: func(ctx context.Context, value string) (context.Context, error) {
+				return region.AddToCtx(ctx, region.Region(value))
+			},
+		},
+		{
+			Key: KeyCurrency,
+			Get: func(ctx context.Context) (string, bool) {
+				cur, err := currencies.GetCurrency(ctx)
+				return cur.String(), err == nil
+			},
+			Set: func(ctx context.Context, value string) (context.Context, error) {
+				cur, err := currency.ParseISO(value)
+				if err != nil {
+					return ctx, err
+				}
+				return currencies.AddToCtx(ctx, cur)
+			},
+		},
+		{
+			// the flag is trusted like the header of the workflow, only services with access to
+			// the namespace can set it
+			Key: KeyAdmin,
+			Get: func(ctx context.Context) (string, bool) {
+				return "true", auth.IsAdmin(ctx)
+			},
+			Set: func(ctx context.Context, value string) (context.Context, error) {
+				admin, err := strconv.ParseBool(value)
+				if err != nil || !admin {
+					return ctx, err
+				}
+				return auth.SetAdmin(ctx), nil
+			},
+		},
+		{
+			Key: KeyRequestID,
+			Get: func(ctx context.Context) (string, bool) {
+				id := middleware.GetReqID(ctx)
+				return id, id != ""
+			},
+			Set: func(ctx context.Context, value string) (context.Context, error) {
+				// the logger adds the request id to every entry
+				return context.WithValue(ctx, middleware.RequestIDKey, value), nil
+			},
+		},
+	}
+}
+
+type propagator struct {
+	fields []Field
+}
+
+// NewContextPropagator returns a propagator for the default fields and the given ones. A field
+// with the key of a default field replaces it. The values are written to a single header when
+// a workflow or activity is started and restored on the context of activities; workflows read
+// them with Value.
+func NewContextPropagator(fields ...Field) workflow.ContextPropagator {
+	all := DefaultFields()
+	for _, f := range fields {
+		replaced := false
+		for i := range all {
+			if all[i].Key == f.Key {
+				all[i], replaced = f, true
+			}
+		}
+		if !replaced {
+			all = append(all, f)
+		}
+	}
+	return &propagator{fields: all}
+}
+
+// Inject writes the values of the context to the header
+func (p *propagator) Inject(ctx context.Context, hw workflow.HeaderWriter) error {
+	values := map[string]string{}
+	for _, f := range p.fields {
+		if v, ok := f.Get(ctx); ok {
+			values[f.Key] = v
+		}
+	}
+	return write(hw, values)
+}
+
+// Extract restores the values of the header on the context
+func (p *propagator) Extract(ctx context.Context, hr workflow.HeaderReader) (context.Context, error) {
+	values, err := read(hr)
+	if err != nil || len(values) == 0 {
+		return ctx, err
+	}
+
+	for _, f := range p.fields {
+		v, ok := values[f.Key]
+		if !ok {
+			continue
+		}
+		if ctx, err = f.Set(ctx, v); err != nil {
+			return ctx, fmt.Errorf("restoring %s from the temporal header: %w", f.Key, err)
+		}
+	}
+	return ctx, nil
+}
+
+// InjectFromWorkflow writes the values of the workflow to the header
+func (p *propagator) InjectFromWorkflow(ctx workflow.Context, hw workflow.HeaderWriter) error {
+	values, _ := ctx.Value(valuesKey).(map[string]string)
+	return write(hw, values)
+}
+
+// ExtractToWorkflow stores the values of the header on the workflow context
+func (p *propagator) ExtractToWorkflow(ctx workflow.Context, hr workflow.HeaderReader) (workflow.Context, error) {
+	values, err := read(hr)
+	if err != nil || len(values) == 0 {
+		return ctx, err
+	}
+	return workflow.WithValue(ctx, valuesKey, values), nil
+}
+
+// Value returns a propagated value in a workflow, e.g. Value(ctx, KeyRegion)
+func Value(ctx workflow.Context, key string) (string, bool) {
+	values, _ := ctx.Value(valuesKey).(map[string]string)
+	v, ok := values[key]
+	return v, ok
+}
+
+// WithValue returns a workflow context with the value set for the activities and child
+// workflows started with it
+func WithValue(ctx workflow.Context, key, value string) workflow.Context {
+	old, _ := ctx.Value(valuesKey).(map[string]string)
+	values := make(map[string]string, len(old)+1)
+	for k, v := range old {
+		values[k] = v
+	}
+	values[key] = value
+	return workflow.WithValue(ctx, valuesKey, values)
+}
+
+func write(hw workflow.HeaderWriter, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	p, err := converter.GetDefaultDataConverter().ToPayload(values)
+	if err != nil {
+		return fmt.Errorf("encoding the temporal header: %w", err)
+	}
+	hw.Set(HeaderKey, p)
+	return nil
+}
+
+func read(hr workflow.HeaderReader) (map[string]string, error) {
+	p, ok := hr.Get(HeaderKey)
+	if !ok {
+		return nil, nil
+	}
+	var values map[string]string
+	if err := converter.GetDefaultDataConverter().FromPayload(p, &values); err != nil {
+		return nil, fmt.Errorf("decoding the temporal header: %w", err)
+	}
+	return values, nil
+}
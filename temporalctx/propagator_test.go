@@ -0,0 +1,142 @@
+package temporalctx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ConradKurth/gokit/middleware/auth"
+	"github.com/ConradKurth/gokit/middleware/currencies"
+	"github.com/ConradKurth/gokit/middleware/region"
+	"github.com/ConradKurth/gokit/middleware/userinfo"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+	"golang.org/x/text/currency"
+)
+
+type tenantKey struct{}
+
+var tenantField = Field{
+	Key: "tenant",
+	Get: func(ctx context.Context) (string, bool) {
+		v, ok := ctx.Value(tenantKey{}).(string)
+		return v, ok
+	},
+	Set: func(ctx context.Context, value string) (context.Context, error) {
+		return context.WithValue(ctx, tenantKey{}, value), nil
+	},
+}
+
+type header map[string]*commonpb.Payload
+
+func (h header) Set(key string, value *commonpb.Payload) {
+	h[key] = value
+}
+
+func (h header) Get(key string) (*commonpb.Payload, bool) {
+	p, ok := h[key]
+	return p, ok
+}
+
+func (h header) ForEachKey(handler func(string, *commonpb.Payload) error) error {
+	for k, v := range h {
+		if err := handler(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type restored struct {
+	UserID     string
+	Admin      bool
+	GroupAdmin bool
+	Region     region.Region
+	Currency   string
+	RequestID  string
+	Tenant     string
+}
+
+func restoredActivity(ctx context.Context) (restored, error) {
+	var r restored
+	r.UserID, _ = userinfo.GetUserID(ctx)
+	r.Admin = auth.IsAdmin(ctx)
+	r.GroupAdmin = userinfo.IsAdmin(ctx)
+	r.Region, _ = region.GetRegion(ctx)
+	cur, err := currencies.GetCurrency(ctx)
+	if err == nil {
+		r.Currency = cur.String()
+	}
+	r.RequestID = middleware.GetReqID(ctx)
+	r.Tenant, _ = ctx.Value(tenantKey{}).(string)
+	return r, nil
+}
+
+func propagationWorkflow(ctx workflow.Context) (restored, error) {
+	if r, _ := Value(ctx, KeyRegion); r != "hongkong" {
+		return restored{}, nil
+	}
+	ctx = WithValue(ctx, "tenant", "acme")
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+
+	var r restored
+	err := workflow.ExecuteActivity(ctx, restoredActivity).Get(ctx, &r)
+	return r, err
+}
+
+func TestContextPropagator(t *testing.T) {
+	p := NewContextPropagator(tenantField)
+
+	ctx := userinfo.SetUserID(context.Background(), "user-1")
+	ctx = userinfo.SetGroups(ctx, []string{"admins", "support"})
+	ctx = auth.SetAdmin(ctx)
+	ctx, err := region.AddToCtx(ctx, region.HongKong)
+	require.NoError(t, err)
+	ctx = currencies.MustInjectCurrency(ctx, currency.HKD)
+	ctx = context.WithValue(ctx, middleware.RequestIDKey, "req-1")
+
+	h := header{}
+	require.NoError(t, p.Inject(ctx, h))
+
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestWorkflowEnvironment()
+	env.SetContextPropagators([]workflow.ContextPropagator{p})
+	env.SetHeader(&commonpb.Header{Fields: h})
+	env.RegisterActivity(restoredActivity)
+
+	env.ExecuteWorkflow(propagationWorkflow)
+	require.NoError(t, env.GetWorkflowError())
+
+	var r restored
+	require.NoError(t, env.GetWorkflowResult(&r))
+	assert.Equal(t, restored{
+		UserID:     "user-1",
+		Admin:      true,
+		GroupAdmin: true,
+		Region:     region.HongKong,
+		Currency:   "HKD",
+		RequestID:  "req-1",
+		Tenant:     "acme",
+	}, r)
+}
+
+func TestContextPropagator_Extract(t *testing.T) {
+	p := NewContextPropagator()
+
+	// nothing is written for an empty context
+	h := header{}
+	require.NoError(t, p.Inject(context.Background(), h))
+	assert.Empty(t, h)
+
+	ctx, err := p.Extract(context.Background(), h)
+	require.NoError(t, err)
+	assert.False(t, auth.IsAdmin(ctx))
+
+	require.NoError(t, write(h, map[string]string{KeyRegion: "atlantis"}))
+	_, err = p.Extract(context.Background(), h)
+	assert.ErrorContains(t, err, "restoring region from the temporal header")
+}
@@ -0,0 +1,138 @@
+package temporalsaga
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ConradKurth/gokit/internal/temporalname"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const defaultTimeout = time.Minute
+
+// Options configures how compensations run
+type Options struct {
+	// Parallel runs all compensations at once instead of one after the other in reverse order
+	Parallel bool
+	// StopOnError stops running further compensations once one failed, only when sequential
+	StopOnError bool
+	// StartToCloseTimeout of the compensation activities, defaults to a minute
+	StartToCloseTimeout time.Duration
+	// RetryPolicy of the compensation activities, defaults to the policy of the server
+	RetryPolicy *temporal.RetryPolicy
+}
+
+// FailedCompensation is a compensation that failed after all its attempts
+type FailedCompensation struct {
+	Activity string
+	Err      error
+}
+
+// CompensationError is returned when compensations failed
+type CompensationError struct {
+	Failed []FailedCompensation
+}
+
+// Error will list the failed compensations
+func (e *CompensationError) Error() string {
+	failed := make([]string, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		failed = append(failed, fmt.Sprintf("%s: %v", f.Activity, f.Err))
+	}
+	return "compensations failed: " + strings.Join(failed, "; ")
+}
+
+// Unwrap returns the errors of the failed compensations
+func (e *CompensationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		errs = append(errs, f.Err)
+	}
+	return errs
+}
+
+type compensation struct {
+	activity interface{}
+	args     []interface{}
+}
+
+// Saga collects the compensations of the steps of a workflow. It must only be used in
+// workflow code.
+type Saga struct {
+	opts          Options
+	compensations []compensation
+}
+
+// New returns an empty saga
+func New(opts Options) *Saga {
+	if opts.StartToCloseTimeout == 0 {
+		opts.StartToCloseTimeout = defaultTimeout
+	}
+	return &Saga{opts: opts}
+}
+
+// AddCompensation registers the activity, or its name, to undo a step that succeeded
+func (s *Saga) AddCompensation(activity interface{}, args ...interface{}) {
+	s.compensations = append(s.compensations, compensation{activity: activity, args: args})
+}
+
+// Step runs the step and registers the compensation if it succeeded
+func (s *Saga) Step(ctx workflow.Context, step func(ctx workflow.Context) error, activity interface{}, args ...interface{}) error {
+	if err := step(ctx); err != nil {
+		return err
+	}
+	s.AddCompensation(activity, args...)
+	return nil
+}
+
+// Compensate runs the registered compensations. They run in a disconnected context, so they
+// also run when the workflow was cancelled. It returns a CompensationError naming the
+// compensations that failed.
+func (s *Saga) Compensate(ctx workflow.Context) error {
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+	// the compensations run like the steps, e.g. with their heartbeat timeout
+	options := workflow.GetActivityOptions(ctx)
+	options.StartToCloseTimeout = s.opts.StartToCloseTimeout
+	options.RetryPolicy = s.opts.RetryPolicy
+	ctx = workflow.WithActivityOptions(ctx, options)
+
+	var failed []FailedCompensation
+	if s.opts.Parallel {
+		futures := make([]workflow.Future, len(s.compensations))
+		for i, c := range s.compensations {
+			futures[i] = workflow.ExecuteActivity(ctx, c.activity, c.args...)
+		}
+		for i, f := range futures {
+			if err := f.Get(ctx, nil); err != nil {
+				failed = append(failed, FailedCompensation{Activity: activityName(s.compensations[i].activity), Err: err})
+			}
+		}
+	} else {
+		for i := len(s.compensations) - 1; i >= 0; i-- {
+			c := s.compensations[i]
+			if err := workflow.ExecuteActivity(ctx, c.activity, c.args...).Get(ctx, nil); err != nil {
+				failed = append(failed, FailedCompensation{Activity: activityName(c.activity), Err: err})
+				if s.opts.StopOnError {
+					break
+				}
+			}
+		}
+	}
+	s.compensations = nil
+
+	if len(failed) > 0 {
+		workflow.GetLogger(ctx).Error("Compensations failed", "failed", len(failed))
+		return &CompensationError{Failed: failed}
+	}
+	return nil
+}
+
+// activityName returns the name an activity function is registered with by default
+func activityName(activity interface{}) string {
+	if name, ok := activity.(string); ok {
+		return name
+	}
+	return temporalname.Func(activity)
+}
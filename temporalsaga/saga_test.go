@@ -0,0 +1,181 @@
+package temporalsaga
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func Charge(_ context.Context, _ string) error      { return nil }
+func Refund(_ context.Context, _ string) error      { return nil }
+func Provision(_ context.Context, _ string) error   { return nil }
+func Deprovision(_ context.Context, _ string) error { return nil }
+func Notify(_ context.Context, _ string) error      { return nil }
+
+func orderWorkflow(opts Options) func(ctx workflow.Context, id string) error {
+	return func(ctx workflow.Context, id string) (err error) {
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+		saga := New(opts)
+		defer func() {
+			if err != nil {
+				err = errors.Join(err, saga.Compensate(ctx))
+			}
+		}()
+
+		activity := func(a interface{}) func(ctx workflow.Context) error {
+			return func(ctx workflow.Context) error {
+				return workflow.ExecuteActivity(ctx, a, id).Get(ctx, nil)
+			}
+		}
+		if err := saga.Step(ctx, activity(Charge), Refund, id); err != nil {
+			return err
+		}
+		if err := saga.Step(ctx, activity(Provision), Deprovision, id); err != nil {
+			return err
+		}
+		return activity(Notify)(ctx)
+	}
+}
+
+func TestSaga(t *testing.T) {
+	noRetry := &temporal.RetryPolicy{MaximumAttempts: 1}
+
+	tt := []struct {
+		Name        string
+		Options     Options
+		Mock        func(env *testsuite.TestWorkflowEnvironment)
+		Compensated []string
+		Err         string
+	}{
+		{
+			Name:    "success runs no compensations",
+			Options: Options{},
+			Mock:    func(env *testsuite.TestWorkflowEnvironment) {},
+		},
+		{
+			Name:    "compensations run in reverse order",
+			Options: Options{},
+			Mock: func(env *testsuite.TestWorkflowEnvironment) {
+				env.OnActivity(Notify, mock.Anything, "o-1").Return(temporal.NewNonRetryableApplicationError("down", "", nil))
+			},
+			Compensated: []string{"Deprovision", "Refund"},
+			Err:         "down",
+		},
+		{
+			Name:    "only succeeded steps are compensated",
+			Options: Options{},
+			Mock: func(env *testsuite.TestWorkflowEnvironment) {
+				env.OnActivity(Provision, mock.Anything, "o-1").Return(temporal.NewNonRetryableApplicationError("full", "", nil))
+			},
+			Compensated: []string{"Refund"},
+			Err:         "full",
+		},
+		{
+			Name:    "failed compensations are reported",
+			Options: Options{RetryPolicy: noRetry},
+			Mock: func(env *testsuite.TestWorkflowEnvironment) {
+				env.OnActivity(Notify, mock.Anything, "o-1").Return(temporal.NewNonRetryableApplicationError("down", "", nil))
+				env.OnActivity(Deprovision, mock.Anything, "o-1").Return(errors.New("stuck"))
+			},
+			Compensated: []string{"Deprovision", "Refund"},
+			Err:         "compensations failed: Deprovision",
+		},
+		{
+			Name:    "stop on error",
+			Options: Options{RetryPolicy: noRetry, StopOnError: true},
+			Mock: func(env *testsuite.TestWorkflowEnvironment) {
+				env.OnActivity(Notify, mock.Anything, "o-1").Return(temporal.NewNonRetryableApplicationError("down", "", nil))
+				env.OnActivity(Deprovision, mock.Anything, "o-1").Return(errors.New("stuck"))
+			},
+			Compensated: []string{"Deprovision"},
+			Err:         "compensations failed: Deprovision",
+		},
+		{
+			Name:    "parallel",
+			Options: Options{Parallel: true},
+			Mock: func(env *testsuite.TestWorkflowEnvironment) {
+				env.OnActivity(Notify, mock.Anything, "o-1").Return(temporal.NewNonRetryableApplicationError("down", "", nil))
+			},
+			Compensated: []string{"Deprovision", "Refund"},
+			Err:         "down",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var s testsuite.WorkflowTestSuite
+			env := s.NewTestWorkflowEnvironment()
+			var compensated []string
+			tc.Mock(env)
+			env.RegisterActivity(Charge)
+			env.RegisterActivity(Refund)
+			env.RegisterActivity(Provision)
+			env.RegisterActivity(Deprovision)
+			env.RegisterActivity(Notify)
+			env.SetOnActivityStartedListener(func(info *activity.Info, _ context.Context, _ converter.EncodedValues) {
+				if name := info.ActivityType.Name; name == "Refund" || name == "Deprovision" {
+					compensated = append(compensated, name)
+				}
+			})
+
+			env.ExecuteWorkflow(orderWorkflow(tc.Options), "o-1")
+			if tc.Err == "" {
+				require.NoError(t, env.GetWorkflowError())
+				assert.Empty(t, compensated)
+				return
+			}
+			require.Error(t, env.GetWorkflowError())
+			assert.Contains(t, env.GetWorkflowError().Error(), tc.Err)
+			if tc.Options.Parallel {
+				assert.ElementsMatch(t, tc.Compensated, compensated)
+			} else {
+				assert.Equal(t, tc.Compensated, compensated)
+			}
+		})
+	}
+}
+
+func TestCompensationError(t *testing.T) {
+	err := &CompensationError{Failed: []FailedCompensation{
+		{Activity: "Refund", Err: errors.New("timeout")},
+		{Activity: "Deprovision", Err: errors.New("stuck")},
+	}}
+	assert.EqualError(t, err, "compensations failed: Refund: timeout; Deprovision: stuck")
+	assert.ErrorIs(t, err, err.Failed[1].Err)
+}
+
+func TestSaga_ActivityOptions(t *testing.T) {
+	wf := func(ctx workflow.Context) error {
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			TaskQueue:           "billing",
+			StartToCloseTimeout: time.Hour,
+			HeartbeatTimeout:    10 * time.Second,
+		})
+		saga := New(Options{})
+		saga.AddCompensation(Refund, "o-1")
+		return saga.Compensate(ctx)
+	}
+
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(Refund)
+	var info activity.Info
+	env.SetOnActivityStartedListener(func(i *activity.Info, _ context.Context, _ converter.EncodedValues) {
+		info = *i
+	})
+
+	env.ExecuteWorkflow(wf)
+	require.NoError(t, env.GetWorkflowError())
+	assert.Equal(t, "billing", info.TaskQueue)
+	assert.Equal(t, 10*time.Second, info.HeartbeatTimeout)
+}
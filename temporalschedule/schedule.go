@@ -0,0 +1,348 @@
+package temporalschedule
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ConradKurth/gokit/internal/temporalname"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+)
+
+const (
+	// OwnerMemo is the memo key holding the owner of a schedule. It is set on creation and
+	// only schedules of the owner are updated or deleted.
+	OwnerMemo = "gokit.owner"
+	// notePrefix starts the note of managed schedules, the note holds the hash of the declaration
+	notePrefix = "managed by gokit: "
+)
+
+// Schedule declares a schedule that starts a workflow
+type Schedule struct {
+	ID   string
+	Spec client.ScheduleSpec
+	// Workflow is the workflow function or its registered name
+	Workflow interface{}
+	Args     []interface{}
+	// WorkflowID of the started workflows, defaults to the schedule ID
+	WorkflowID string
+	// TaskQueue of the started workflows, defaults to the task queue of the reconciler
+	TaskQueue      string
+	Overlap        enumspb.ScheduleOverlapPolicy
+	CatchupWindow  time.Duration
+	PauseOnFailure bool
+	Paused         bool
+}
+
+// ChangeType is the type of a change to a schedule
+type ChangeType string
+
+const (
+	Create ChangeType = "create"
+	Update ChangeType = "update"
+	Delete ChangeType = "delete"
+)
+
+// FieldChange is a changed field of an updated schedule
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Change is a change the reconciler applies to a schedule
+type Change struct {
+	Type   ChangeType
+	ID     string
+	Fields []FieldChange
+}
+
+// String will format the change like a diff
+func (c Change) String() string {
+	switch c.Type {
+	case Create:
+		return "+ " + c.ID
+	case Delete:
+		return "- " + c.ID
+	}
+
+	fields := make([]string, 0, len(c.Fields))
+	for _, f := range c.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s -> %s", f.Field, f.Old, f.New))
+	}
+	return fmt.Sprintf("~ %s (%s)", c.ID, strings.Join(fields, ", "))
+}
+
+// Reconciler makes the schedules of an owner match their declaration
+type Reconciler struct {
+	client    client.ScheduleClient
+	owner     string
+	taskQueue string
+}
+
+// NewReconciler returns a reconciler for the schedules of the owner, usually the service name
+func NewReconciler(c client.ScheduleClient, owner, taskQueue string) *Reconciler {
+	return &Reconciler{
+		client:    c,
+		owner:     owner,
+		taskQueue: taskQueue,
+	}
+}
+
+type declared struct {
+	schedule Schedule
+	workflow string
+	note     string
+}
+
+type existing struct {
+	entry *client.ScheduleListEntry
+	owned bool
+}
+
+// Reconcile creates declared schedules that are missing, updates the changed ones and deletes
+// the owned schedules that are no longer declared. With dryRun nothing is changed, the
+// returned changes are what would be applied.
+func (r *Reconciler) Reconcile(ctx context.Context, schedules []Schedule, dryRun bool) ([]Change, error) {
+	want, err := r.declare(schedules)
+	if err != nil {
+		return nil, err
+	}
+	have, err := r.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, d := range want {
+		e, ok := have[d.schedule.ID]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Type: Create, ID: d.schedule.ID})
+		case !e.owned:
+			return nil, fmt.Errorf("schedule %q exists and is not owned by %s", d.schedule.ID, r.owner)
+		case e.entry.Note != d.note:
+			c, err := r.diff(ctx, d)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, c)
+		}
+	}
+	for id, e := range have {
+		if _, ok := want[id]; !ok && e.owned {
+			changes = append(changes, Change{Type: Delete, ID: id})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ID < changes[j].ID
+	})
+
+	if dryRun {
+		return changes, nil
+	}
+	for _, c := range changes {
+		if err := r.apply(ctx, c, want[c.ID]); err != nil {
+			return nil, fmt.Errorf("%s schedule %q: %w", c.Type, c.ID, err)
+		}
+	}
+	return changes, nil
+}
+
+func (r *Reconciler) declare(schedules []Schedule) (map[string]declared, error) {
+	want := make(map[string]declared, len(schedules))
+	for _, s := range schedules {
+		if s.ID == "" {
+			return nil, errors.New("schedule without id")
+		}
+		if _, ok := want[s.ID]; ok {
+			return nil, fmt.Errorf("schedule %q is declared twice", s.ID)
+		}
+		if s.WorkflowID == "" {
+			s.WorkflowID = s.ID
+		}
+		if s.TaskQueue == "" {
+			s.TaskQueue = r.taskQueue
+		}
+
+		workflow, err := workflowName(s.Workflow)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", s.ID, err)
+		}
+		note, err := noteFor(s, workflow)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", s.ID, err)
+		}
+		want[s.ID] = declared{schedule: s, workflow: workflow, note: note}
+	}
+	return want, nil
+}
+
+func (r *Reconciler) list(ctx context.Context) (map[string]existing, error) {
+	it, err := r.client.List(ctx, client.ScheduleListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing schedules: %w", err)
+	}
+
+	have := map[string]existing{}
+	for it.HasNext() {
+		entry, err := it.Next()
+		if err != nil {
+			return nil, fmt.Errorf("listing schedules: %w", err)
+		}
+		have[entry.ID] = existing{entry: entry, owned: r.isOwner(entry)}
+	}
+	return have, nil
+}
+
+func (r *Reconciler) isOwner(entry *client.ScheduleListEntry) bool {
+	if entry.Memo == nil {
+		return false
+	}
+	p, ok := entry.Memo.Fields[OwnerMemo]
+	if !ok {
+		return false
+	}
+	var owner string
+	if err := converter.GetDefaultDataConverter().FromPayload(p, &owner); err != nil {
+		return false
+	}
+	return owner == r.owner
+}
+
+// diff describes the schedule to find the changed fields. The spec and arguments can not be
+// compared as the server normalizes them, they are reported together when nothing else changed.
+func (r *Reconciler) diff(ctx context.Context, d declared) (Change, error) {
+	desc, err := r.client.GetHandle(ctx, d.schedule.ID).Describe(ctx)
+	if err != nil {
+		return Change{}, fmt.Errorf("describing schedule %q: %w", d.schedule.ID, err)
+	}
+
+	c := Change{Type: Update, ID: d.schedule.ID}
+	add := func(field string, old, new interface{}) {
+		o, n := fmt.Sprint(old), fmt.Sprint(new)
+		if o != n {
+			c.Fields = append(c.Fields, FieldChange{Field: field, Old: o, New: n})
+		}
+	}
+	if a, ok := desc.Schedule.Action.(*client.ScheduleWorkflowAction); ok {
+		// described actions hold the workflow type name
+		old, _ := workflowName(a.Workflow)
+		add("workflow", old, d.workflow)
+		add("workflowID", a.ID, d.schedule.WorkflowID)
+		add("taskQueue", a.TaskQueue, d.schedule.TaskQueue)
+	}
+	if p := desc.Schedule.Policy; p != nil {
+		add("overlap", p.Overlap, d.schedule.Overlap)
+		add("catchupWindow", p.CatchupWindow, d.schedule.CatchupWindow)
+		add("pauseOnFailure", p.PauseOnFailure, d.schedule.PauseOnFailure)
+	}
+	if s := desc.Schedule.State; s != nil {
+		add("paused", s.Paused, d.schedule.Paused)
+	}
+	if len(c.Fields) == 0 {
+		c.Fields = append(c.Fields, FieldChange{Field: "spec or args", Old: "previous", New: "declared"})
+	}
+	return c, nil
+}
+
+func (r *Reconciler) apply(ctx context.Context, c Change, d declared) error {
+	switch c.Type {
+	case Create:
+		err := r.create(ctx, d)
+		if !errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+			return err
+		}
+		// the listing is eventually consistent, a recently created schedule is updated instead
+		return r.update(ctx, d)
+	case Update:
+		return r.update(ctx, d)
+	case Delete:
+		return r.client.GetHandle(ctx, c.ID).Delete(ctx)
+	}
+	return nil
+}
+
+func (r *Reconciler) create(ctx context.Context, d declared) error {
+	s := d.schedule
+	_, err := r.client.Create(ctx, client.ScheduleOptions{
+		ID:             s.ID,
+		Spec:           s.Spec,
+		Action:         action(s),
+		Overlap:        s.Overlap,
+		CatchupWindow:  s.CatchupWindow,
+		PauseOnFailure: s.PauseOnFailure,
+		Paused:         s.Paused,
+		Note:           d.note,
+		Memo:           map[string]interface{}{OwnerMemo: r.owner},
+	})
+	return err
+}
+
+func (r *Reconciler) update(ctx context.Context, d declared) error {
+	s := d.schedule
+	return r.client.GetHandle(ctx, s.ID).Update(ctx, client.ScheduleUpdateOptions{
+		DoUpdate: func(in client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+			schedule := in.Description.Schedule
+			spec := s.Spec
+			schedule.Spec = &spec
+			schedule.Action = action(s)
+			schedule.Policy = &client.SchedulePolicies{
+				Overlap:        s.Overlap,
+				CatchupWindow:  s.CatchupWindow,
+				PauseOnFailure: s.PauseOnFailure,
+			}
+			state := client.ScheduleState{}
+			if schedule.State != nil {
+				state = *schedule.State
+			}
+			state.Note = d.note
+			state.Paused = s.Paused
+			schedule.State = &state
+			return &client.ScheduleUpdate{Schedule: &schedule}, nil
+		},
+	})
+}
+
+func action(s Schedule) *client.ScheduleWorkflowAction {
+	return &client.ScheduleWorkflowAction{
+		ID:        s.WorkflowID,
+		Workflow:  s.Workflow,
+		Args:      s.Args,
+		TaskQueue: s.TaskQueue,
+	}
+}
+
+// noteFor returns the note with the hash of everything that is declared
+func noteFor(s Schedule, workflow string) (string, error) {
+	b, err := json.Marshal(struct {
+		Schedule
+		Workflow string
+	}{s, workflow})
+	if err != nil {
+		return "", fmt.Errorf("hashing schedule: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return notePrefix + hex.EncodeToString(sum[:8]), nil
+}
+
+// workflowName returns the name a workflow function is registered with by default
+func workflowName(w interface{}) (string, error) {
+	if name, ok := w.(string); ok && name != "" {
+		return name, nil
+	}
+	if reflect.ValueOf(w).Kind() != reflect.Func {
+		return "", errors.New("workflow must be a function or its name")
+	}
+	return temporalname.Func(w), nil
+}
@@ -0,0 +1,213 @@
+package temporalschedule
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/workflow"
+)
+
+// fakeClient keeps schedules in memory
+type fakeClient struct {
+	schedules map[string]*client.ScheduleDescription
+	notes     map[string]string
+	deleted   []string
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		schedules: map[string]*client.ScheduleDescription{},
+		notes:     map[string]string{},
+	}
+}
+
+func (f *fakeClient) add(id, owner, note string, s client.Schedule) {
+	memo := &commonpb.Memo{Fields: map[string]*commonpb.Payload{}}
+	if owner != "" {
+		p, _ := converter.GetDefaultDataConverter().ToPayload(owner)
+		memo.Fields[OwnerMemo] = p
+	}
+	f.schedules[id] = &client.ScheduleDescription{Schedule: s, Memo: memo}
+	f.notes[id] = note
+}
+
+func (f *fakeClient) Create(_ context.Context, o client.ScheduleOptions) (client.ScheduleHandle, error) {
+	f.add(o.ID, o.Memo[OwnerMemo].(string), o.Note, client.Schedule{
+		Action: o.Action,
+		Spec:   &o.Spec,
+		Policy: &client.SchedulePolicies{Overlap: o.Overlap},
+		State:  &client.ScheduleState{Note: o.Note, Paused: o.Paused},
+	})
+	return &fakeHandle{f: f, id: o.ID}, nil
+}
+
+func (f *fakeClient) List(_ context.Context, _ client.ScheduleListOptions) (client.ScheduleListIterator, error) {
+	it := &fakeIterator{}
+	for id, d := range f.schedules {
+		it.entries = append(it.entries, &client.ScheduleListEntry{ID: id, Note: f.notes[id], Memo: d.Memo})
+	}
+	sort.Slice(it.entries, func(i, j int) bool { return it.entries[i].ID < it.entries[j].ID })
+	return it, nil
+}
+
+func (f *fakeClient) GetHandle(_ context.Context, id string) client.ScheduleHandle {
+	return &fakeHandle{f: f, id: id}
+}
+
+type fakeIterator struct {
+	entries []*client.ScheduleListEntry
+}
+
+func (i *fakeIterator) HasNext() bool {
+	return len(i.entries) > 0
+}
+
+func (i *fakeIterator) Next() (*client.ScheduleListEntry, error) {
+	e := i.entries[0]
+	i.entries = i.entries[1:]
+	return e, nil
+}
+
+type fakeHandle struct {
+	client.ScheduleHandle
+	f  *fakeClient
+	id string
+}
+
+func (h *fakeHandle) Describe(_ context.Context) (*client.ScheduleDescription, error) {
+	return h.f.schedules[h.id], nil
+}
+
+func (h *fakeHandle) Update(_ context.Context, o client.ScheduleUpdateOptions) error {
+	d := h.f.schedules[h.id]
+	u, err := o.DoUpdate(client.ScheduleUpdateInput{Description: *d})
+	if err != nil {
+		return err
+	}
+	d.Schedule = *u.Schedule
+	h.f.notes[h.id] = u.Schedule.State.Note
+	return nil
+}
+
+func (h *fakeHandle) Delete(_ context.Context) error {
+	delete(h.f.schedules, h.id)
+	h.f.deleted = append(h.f.deleted, h.id)
+	return nil
+}
+
+func ReportWorkflow(_ workflow.Context) error {
+	return nil
+}
+
+func CleanupWorkflow(_ workflow.Context) error {
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeClient()
+	r := NewReconciler(f, "reports", "default")
+
+	report := Schedule{
+		ID:       "daily-report",
+		Spec:     client.ScheduleSpec{CronExpressions: []string{"0 6 * * *"}},
+		Workflow: ReportWorkflow,
+	}
+	cleanup := Schedule{
+		ID:       "cleanup",
+		Spec:     client.ScheduleSpec{CronExpressions: []string{"0 * * * *"}},
+		Workflow: CleanupWorkflow,
+	}
+	f.add("other-service", "billing", "", client.Schedule{})
+
+	changes, err := r.Reconcile(ctx, []Schedule{report, cleanup}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Type: Create, ID: "cleanup"}, {Type: Create, ID: "daily-report"}}, changes)
+	a := f.schedules["daily-report"].Schedule.Action.(*client.ScheduleWorkflowAction)
+	assert.Equal(t, "daily-report", a.ID)
+	assert.Equal(t, "default", a.TaskQueue)
+
+	// nothing changed
+	changes, err = r.Reconcile(ctx, []Schedule{report, cleanup}, false)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// the dry run shows the diff without applying it
+	report.TaskQueue = "reports"
+	report.Overlap = enumspb.SCHEDULE_OVERLAP_POLICY_SKIP
+	changes, err = r.Reconcile(ctx, []Schedule{report}, true)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "- cleanup", changes[0].String())
+	assert.Equal(t, "~ daily-report (taskQueue: default -> reports, overlap: Unspecified -> Skip)", changes[1].String())
+	assert.Contains(t, f.schedules, "cleanup")
+
+	changes, err = r.Reconcile(ctx, []Schedule{report}, false)
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, []string{"cleanup"}, f.deleted)
+	assert.Equal(t, "reports", f.schedules["daily-report"].Schedule.Action.(*client.ScheduleWorkflowAction).TaskQueue)
+	assert.Contains(t, f.schedules, "other-service")
+
+	changes, err = r.Reconcile(ctx, []Schedule{report}, false)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestReconcile_Validation(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeClient()
+	f.add("taken", "billing", "", client.Schedule{})
+	r := NewReconciler(f, "reports", "default")
+
+	tt := []struct {
+		Name      string
+		Schedules []Schedule
+		Err       string
+	}{
+		{
+			Name:      "missing id",
+			Schedules: []Schedule{{Workflow: ReportWorkflow}},
+			Err:       "schedule without id",
+		},
+		{
+			Name:      "declared twice",
+			Schedules: []Schedule{{ID: "a", Workflow: ReportWorkflow}, {ID: "a", Workflow: ReportWorkflow}},
+			Err:       `schedule "a" is declared twice`,
+		},
+		{
+			Name:      "no workflow",
+			Schedules: []Schedule{{ID: "a"}},
+			Err:       `schedule "a": workflow must be a function or its name`,
+		},
+		{
+			Name:      "owned by another service",
+			Schedules: []Schedule{{ID: "taken", Workflow: ReportWorkflow}},
+			Err:       `schedule "taken" exists and is not owned by reports`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := r.Reconcile(ctx, tc.Schedules, false)
+			assert.EqualError(t, err, tc.Err)
+		})
+	}
+}
+
+func TestWorkflowName(t *testing.T) {
+	name, err := workflowName(ReportWorkflow)
+	require.NoError(t, err)
+	assert.Equal(t, "ReportWorkflow", name)
+
+	name, err = workflowName("Custom")
+	require.NoError(t, err)
+	assert.Equal(t, "Custom", name)
+}
@@ -4,33 +4,44 @@ import (
 	"context"
 
 	"github.com/stretchr/testify/mock"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/converter"
 )
 
+var _ client.Client = (*MockClient)(nil)
+
+// MockClient mocks every method of client.Client. Variadic arguments are passed to the mock as
+// one slice, and only when there are some.
 type MockClient struct {
 	mock.Mock
-	client.Client
 }
 
-func (m *MockClient) SignalWithStartWorkflow(ctx context.Context, workflowID, signalName string, signalArg interface{}, options client.StartWorkflowOptions, workflow interface{}, args ...interface{}) (client.WorkflowRun, error) {
-	var arg mock.Arguments
+// called calls the method of the mock with the variadic arguments as last argument when
+// there are some
+func (m *MockClient) called(method string, args []interface{}, arguments ...interface{}) mock.Arguments {
 	if len(args) > 0 {
-		arg = m.Called(ctx, workflowID, signalName, signalArg, options, workflow, args)
-	} else {
-		arg = m.Called(ctx, workflowID, signalName, signalArg, options, workflow)
+		arguments = append(arguments, args)
 	}
-	return arg.Get(0).(client.WorkflowRun), arg.Error(1)
+	return m.MethodCalled(method, arguments...)
+}
+
+// get returns the value at the index when it is set, so a mock can return nil
+func get[T any](args mock.Arguments, index int) T {
+	v, _ := args.Get(index).(T)
+	return v
 }
 
 func (m *MockClient) ExecuteWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflow interface{}, args ...interface{}) (client.WorkflowRun, error) {
-	var arg mock.Arguments
-	if len(args) > 0 {
-		arg = m.Called(ctx, options, workflow, args)
-	} else {
-		arg = m.Called(ctx, options, workflow)
-	}
-	return arg.Get(0).(client.WorkflowRun), arg.Error(1)
+	arg := m.called("ExecuteWorkflow", args, ctx, options, workflow)
+	return get[client.WorkflowRun](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) GetWorkflow(ctx context.Context, workflowID string, runID string) client.WorkflowRun {
+	arg := m.Called(ctx, workflowID, runID)
+	return get[client.WorkflowRun](arg, 0)
 }
 
 func (m *MockClient) SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
@@ -38,7 +49,165 @@ func (m *MockClient) SignalWorkflow(ctx context.Context, workflowID string, runI
 	return a.Error(0)
 }
 
+func (m *MockClient) SignalWithStartWorkflow(ctx context.Context, workflowID, signalName string, signalArg interface{}, options client.StartWorkflowOptions, workflow interface{}, args ...interface{}) (client.WorkflowRun, error) {
+	arg := m.called("SignalWithStartWorkflow", args, ctx, workflowID, signalName, signalArg, options, workflow)
+	return get[client.WorkflowRun](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) CancelWorkflow(ctx context.Context, workflowID string, runID string) error {
+	arg := m.Called(ctx, workflowID, runID)
+	return arg.Error(0)
+}
+
+func (m *MockClient) TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details ...interface{}) error {
+	arg := m.called("TerminateWorkflow", details, ctx, workflowID, runID, reason)
+	return arg.Error(0)
+}
+
+func (m *MockClient) GetWorkflowHistory(ctx context.Context, workflowID string, runID string, isLongPoll bool, filterType enums.HistoryEventFilterType) client.HistoryEventIterator {
+	arg := m.Called(ctx, workflowID, runID, isLongPoll, filterType)
+	return get[client.HistoryEventIterator](arg, 0)
+}
+
+func (m *MockClient) CompleteActivity(ctx context.Context, taskToken []byte, result interface{}, err error) error {
+	arg := m.Called(ctx, taskToken, result, err)
+	return arg.Error(0)
+}
+
+func (m *MockClient) CompleteActivityByID(ctx context.Context, namespace, workflowID, runID, activityID string, result interface{}, err error) error {
+	arg := m.Called(ctx, namespace, workflowID, runID, activityID, result, err)
+	return arg.Error(0)
+}
+
+func (m *MockClient) RecordActivityHeartbeat(ctx context.Context, taskToken []byte, details ...interface{}) error {
+	arg := m.called("RecordActivityHeartbeat", details, ctx, taskToken)
+	return arg.Error(0)
+}
+
+func (m *MockClient) RecordActivityHeartbeatByID(ctx context.Context, namespace, workflowID, runID, activityID string, details ...interface{}) error {
+	arg := m.called("RecordActivityHeartbeatByID", details, ctx, namespace, workflowID, runID, activityID)
+	return arg.Error(0)
+}
+
+func (m *MockClient) ListClosedWorkflow(ctx context.Context, request *workflowservice.ListClosedWorkflowExecutionsRequest) (*workflowservice.ListClosedWorkflowExecutionsResponse, error) {
+	arg := m.Called(ctx, request)
+	return get[*workflowservice.ListClosedWorkflowExecutionsResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) ListOpenWorkflow(ctx context.Context, request *workflowservice.ListOpenWorkflowExecutionsRequest) (*workflowservice.ListOpenWorkflowExecutionsResponse, error) {
+	arg := m.Called(ctx, request)
+	return get[*workflowservice.ListOpenWorkflowExecutionsResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) ListWorkflow(ctx context.Context, request *workflowservice.ListWorkflowExecutionsRequest) (*workflowservice.ListWorkflowExecutionsResponse, error) {
+	arg := m.Called(ctx, request)
+	return get[*workflowservice.ListWorkflowExecutionsResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) ListArchivedWorkflow(ctx context.Context, request *workflowservice.ListArchivedWorkflowExecutionsRequest) (*workflowservice.ListArchivedWorkflowExecutionsResponse, error) {
+	arg := m.Called(ctx, request)
+	return get[*workflowservice.ListArchivedWorkflowExecutionsResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) ScanWorkflow(ctx context.Context, request *workflowservice.ScanWorkflowExecutionsRequest) (*workflowservice.ScanWorkflowExecutionsResponse, error) {
+	arg := m.Called(ctx, request)
+	return get[*workflowservice.ScanWorkflowExecutionsResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) CountWorkflow(ctx context.Context, request *workflowservice.CountWorkflowExecutionsRequest) (*workflowservice.CountWorkflowExecutionsResponse, error) {
+	arg := m.Called(ctx, request)
+	return get[*workflowservice.CountWorkflowExecutionsResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) GetSearchAttributes(ctx context.Context) (*workflowservice.GetSearchAttributesResponse, error) {
+	arg := m.Called(ctx)
+	return get[*workflowservice.GetSearchAttributesResponse](arg, 0), arg.Error(1)
+}
+
 func (m *MockClient) QueryWorkflow(ctx context.Context, workflowID string, runID string, queryType string, args ...interface{}) (converter.EncodedValue, error) {
 	a := m.Called(ctx, workflowID, runID, queryType, args)
-	return a.Get(0).(converter.EncodedValue), a.Error(1)
+	return get[converter.EncodedValue](a, 0), a.Error(1)
+}
+
+func (m *MockClient) QueryWorkflowWithOptions(ctx context.Context, request *client.QueryWorkflowWithOptionsRequest) (*client.QueryWorkflowWithOptionsResponse, error) {
+	arg := m.Called(ctx, request)
+	return get[*client.QueryWorkflowWithOptionsResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+	arg := m.Called(ctx, workflowID, runID)
+	return get[*workflowservice.DescribeWorkflowExecutionResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) DescribeTaskQueue(ctx context.Context, taskqueue string, taskqueueType enums.TaskQueueType) (*workflowservice.DescribeTaskQueueResponse, error) {
+	arg := m.Called(ctx, taskqueue, taskqueueType)
+	return get[*workflowservice.DescribeTaskQueueResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) DescribeTaskQueueEnhanced(ctx context.Context, options client.DescribeTaskQueueEnhancedOptions) (client.TaskQueueDescription, error) {
+	arg := m.Called(ctx, options)
+	return get[client.TaskQueueDescription](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) ResetWorkflowExecution(ctx context.Context, request *workflowservice.ResetWorkflowExecutionRequest) (*workflowservice.ResetWorkflowExecutionResponse, error) {
+	arg := m.Called(ctx, request)
+	return get[*workflowservice.ResetWorkflowExecutionResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) UpdateWorkerBuildIdCompatibility(ctx context.Context, options *client.UpdateWorkerBuildIdCompatibilityOptions) error {
+	arg := m.Called(ctx, options)
+	return arg.Error(0)
+}
+
+func (m *MockClient) GetWorkerBuildIdCompatibility(ctx context.Context, options *client.GetWorkerBuildIdCompatibilityOptions) (*client.WorkerBuildIDVersionSets, error) {
+	arg := m.Called(ctx, options)
+	return get[*client.WorkerBuildIDVersionSets](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) GetWorkerTaskReachability(ctx context.Context, options *client.GetWorkerTaskReachabilityOptions) (*client.WorkerTaskReachability, error) {
+	arg := m.Called(ctx, options)
+	return get[*client.WorkerTaskReachability](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) UpdateWorkerVersioningRules(ctx context.Context, options client.UpdateWorkerVersioningRulesOptions) (*client.WorkerVersioningRules, error) {
+	arg := m.Called(ctx, options)
+	return get[*client.WorkerVersioningRules](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) GetWorkerVersioningRules(ctx context.Context, options client.GetWorkerVersioningOptions) (*client.WorkerVersioningRules, error) {
+	arg := m.Called(ctx, options)
+	return get[*client.WorkerVersioningRules](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) CheckHealth(ctx context.Context, request *client.CheckHealthRequest) (*client.CheckHealthResponse, error) {
+	arg := m.Called(ctx, request)
+	return get[*client.CheckHealthResponse](arg, 0), arg.Error(1)
+}
+
+func (m *MockClient) UpdateWorkflow(ctx context.Context, options client.UpdateWorkflowOptions) (client.WorkflowUpdateHandle, error) {
+	arg := m.Called(ctx, options)
+	return get[client.WorkflowUpdateHandle](arg, 0), arg.Error(1)
 }
+
+func (m *MockClient) GetWorkflowUpdateHandle(ref client.GetWorkflowUpdateHandleOptions) client.WorkflowUpdateHandle {
+	arg := m.Called(ref)
+	return get[client.WorkflowUpdateHandle](arg, 0)
+}
+
+func (m *MockClient) WorkflowService() workflowservice.WorkflowServiceClient {
+	arg := m.Called()
+	return get[workflowservice.WorkflowServiceClient](arg, 0)
+}
+
+func (m *MockClient) OperatorService() operatorservice.OperatorServiceClient {
+	arg := m.Called()
+	return get[operatorservice.OperatorServiceClient](arg, 0)
+}
+
+func (m *MockClient) ScheduleClient() client.ScheduleClient {
+	arg := m.Called()
+	return get[client.ScheduleClient](arg, 0)
+}
+
+// Close is not recorded, so clients can be closed without an expectation
+func (m *MockClient) Close() {}
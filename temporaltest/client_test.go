@@ -0,0 +1,35 @@
+package temporaltest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/api/workflowservice/v1"
+)
+
+func Test_MockClient(t *testing.T) {
+	ctx := context.Background()
+	c := &MockClient{}
+
+	c.On("TerminateWorkflow", ctx, "id", "", "reason").Once().Return(nil)
+	c.On("TerminateWorkflow", ctx, "id", "", "reason", []interface{}{"detail"}).Once().Return(errors.New("failed"))
+	c.On("DescribeWorkflowExecution", ctx, "id", "").Once().Return(nil, errors.New("not found"))
+	c.On("CountWorkflow", ctx, mock.Anything).Once().Return(&workflowservice.CountWorkflowExecutionsResponse{Count: 2}, nil)
+
+	assert.NoError(t, c.TerminateWorkflow(ctx, "id", "", "reason"))
+	assert.EqualError(t, c.TerminateWorkflow(ctx, "id", "", "reason", "detail"), "failed")
+
+	resp, err := c.DescribeWorkflowExecution(ctx, "id", "")
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "not found")
+
+	count, err := c.CountWorkflow(ctx, &workflowservice.CountWorkflowExecutionsRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count.Count)
+
+	c.Close()
+	c.AssertExpectations(t)
+}
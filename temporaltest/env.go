@@ -0,0 +1,299 @@
+package temporaltest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ConradKurth/gokit/config"
+	"github.com/ConradKurth/gokit/internal/temporalname"
+	"github.com/ConradKurth/gokit/logger"
+	loggerMiddleware "github.com/ConradKurth/gokit/middleware/logger"
+	"github.com/ConradKurth/gokit/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type envOptions struct {
+	config map[string]interface{}
+}
+
+// WithConfig sets the config of the environment, it is empty by default
+func WithConfig(cfg map[string]interface{}) func(*envOptions) {
+	return func(o *envOptions) {
+		o.config = cfg
+	}
+}
+
+// Env is a workflow test environment of the sdk with a gokit logger and config. Activities
+// get the logger from their context, the logs are kept in Logs. The expectations of mocks
+// are asserted when the test ends.
+type Env struct {
+	*testsuite.TestWorkflowEnvironment
+
+	Config *config.Config
+	Logger logger.Logger
+	Logs   *observer.ObservedLogs
+
+	t          *testing.T
+	activities map[string]interface{}
+	workflows  map[string]interface{}
+	children   []string
+}
+
+// NewEnv returns a test environment for workflows
+func NewEnv(t *testing.T, opts ...func(*envOptions)) *Env {
+	o := envOptions{config: map[string]interface{}{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	core, logs := observer.New(zap.DebugLevel)
+	log := logger.NewWithLogger(zap.New(core))
+
+	var s testsuite.WorkflowTestSuite
+	s.SetLogger(logger.NewLoggerAdapter(log))
+
+	e := &Env{
+		TestWorkflowEnvironment: s.NewTestWorkflowEnvironment(),
+		Config:                  config.LoadConfig(config.WithMap(o.config)),
+		Logger:                  log,
+		Logs:                    logs,
+		t:                       t,
+		activities:              map[string]interface{}{},
+		workflows:               map[string]interface{}{},
+	}
+	e.SetWorkerOptions(worker.Options{
+		BackgroundActivityContext: logger.SetLogger(context.Background(), log),
+		Interceptors:              []interceptor.WorkerInterceptor{loggerMiddleware.NewTemporalInterceptor(log)},
+	})
+	e.SetOnChildWorkflowStartedListener(func(info *workflow.Info, _ workflow.Context, _ converter.EncodedValues) {
+		e.children = append(e.children, info.WorkflowType.Name)
+	})
+	t.Cleanup(func() {
+		e.AssertExpectations(t)
+	})
+	return e
+}
+
+// Register registers the workflows and activities of the registrations like the worker of
+// the service does
+func (e *Env) Register(registrations ...service.WorkerRegistration) {
+	for _, r := range registrations {
+		r.RegisterWithWorker(&registry{Registry: e.TestWorkflowEnvironment, env: e})
+	}
+}
+
+// Activity starts the mock of an activity function or the name of a registered activity
+func (e *Env) Activity(activity interface{}) *Mock {
+	return &Mock{env: e, target: activity, fn: e.lookup(activity, e.activities)}
+}
+
+// ChildWorkflow starts the mock of a child workflow function or the name of a registered
+// workflow
+func (e *Env) ChildWorkflow(workflow interface{}) *Mock {
+	return &Mock{env: e, target: workflow, fn: e.lookup(workflow, e.workflows), workflow: true}
+}
+
+func (e *Env) lookup(target interface{}, registered map[string]interface{}) interface{} {
+	if name, ok := target.(string); ok {
+		return registered[name]
+	}
+	return target
+}
+
+// Execute runs the workflow and fails the test when it did not complete
+func (e *Env) Execute(workflow interface{}, args ...interface{}) {
+	e.ExecuteWorkflow(workflow, args...)
+	require.True(e.t, e.IsWorkflowCompleted(), "workflow did not complete")
+}
+
+// RequireResult decodes the result of the workflow and fails the test when it failed
+func (e *Env) RequireResult(valuePtr interface{}) {
+	require.NoError(e.t, e.GetWorkflowError())
+	require.NoError(e.t, e.GetWorkflowResult(valuePtr))
+}
+
+// SignalAfter sends the signal once the workflow ran for the duration
+func (e *Env) SignalAfter(d time.Duration, signalName string, value interface{}) {
+	e.RegisterDelayedCallback(func() {
+		e.SignalWorkflow(signalName, value)
+	}, d)
+}
+
+// ExpectExternalSignal expects the workflow to signal another workflow once
+func (e *Env) ExpectExternalSignal(workflowID, signalName string, value interface{}) *testsuite.MockCallWrapper {
+	return e.OnSignalExternalWorkflow(mock.Anything, workflowID, mock.Anything, signalName, value).Return(nil).Once()
+}
+
+// RequireQuery queries the workflow, decodes the result and fails the test on errors
+func (e *Env) RequireQuery(queryType string, valuePtr interface{}, args ...interface{}) {
+	value, err := e.QueryWorkflow(queryType, args...)
+	require.NoError(e.t, err)
+	require.NoError(e.t, value.Get(valuePtr))
+}
+
+// AssertQuery asserts the result of the query equals the expected value
+func (e *Env) AssertQuery(queryType string, expected interface{}, args ...interface{}) bool {
+	value, err := e.QueryWorkflow(queryType, args...)
+	if !assert.NoError(e.t, err) {
+		return false
+	}
+	actual := reflect.New(reflect.TypeOf(expected))
+	if !assert.NoError(e.t, value.Get(actual.Interface())) {
+		return false
+	}
+	return assert.Equal(e.t, expected, actual.Elem().Interface())
+}
+
+// ChildWorkflows returns the types of the started child workflows in order
+func (e *Env) ChildWorkflows() []string {
+	return e.children
+}
+
+// AssertChildStarted asserts a child workflow of the type was started
+func (e *Env) AssertChildStarted(workflowType string) bool {
+	return assert.Contains(e.t, e.children, workflowType, "child workflow %s was not started", workflowType)
+}
+
+// Mock is the expectation on an activity or child workflow, it is set up by Returns, Fails
+// or Do. Without With it matches any arguments.
+type Mock struct {
+	env      *Env
+	target   interface{}
+	fn       interface{}
+	workflow bool
+	args     []interface{}
+}
+
+// With matches only calls with the arguments, without the context
+func (m *Mock) With(args ...interface{}) *Mock {
+	m.args = args
+	return m
+}
+
+// Returns returns the values, the nil error can be omitted
+func (m *Mock) Returns(values ...interface{}) *testsuite.MockCallWrapper {
+	if m.fn != nil && len(values) == reflect.TypeOf(m.fn).NumOut()-1 {
+		values = append(values, nil)
+	}
+	return m.on().Return(values...)
+}
+
+// Fails returns the error and the zero result
+func (m *Mock) Fails(err error) *testsuite.MockCallWrapper {
+	if m.fn == nil {
+		panic(fmt.Sprintf("temporaltest: %v is not registered, use Returns", m.target))
+	}
+	fnType := reflect.TypeOf(m.fn)
+	values := make([]interface{}, 0, fnType.NumOut())
+	for i := 0; i < fnType.NumOut()-1; i++ {
+		values = append(values, reflect.Zero(fnType.Out(i)).Interface())
+	}
+	return m.on().Return(append(values, err)...)
+}
+
+// Do runs fn instead, it must have the signature of the mocked function
+func (m *Mock) Do(fn interface{}) *testsuite.MockCallWrapper {
+	return m.on().Return(fn)
+}
+
+func (m *Mock) on() *testsuite.MockCallWrapper {
+	args := m.args
+	if args == nil {
+		if m.fn == nil {
+			panic(fmt.Sprintf("temporaltest: %v is not registered, use With", m.target))
+		}
+		args = make([]interface{}, reflect.TypeOf(m.fn).NumIn())
+		for i := range args {
+			args[i] = mock.Anything
+		}
+	} else if m.hasContext() {
+		args = append([]interface{}{mock.Anything}, args...)
+	}
+
+	if m.workflow {
+		return m.env.OnWorkflow(m.target, args...)
+	}
+	return m.env.OnActivity(m.target, args...)
+}
+
+// hasContext reports if the function takes a context first, it is assumed for unknown functions
+func (m *Mock) hasContext() bool {
+	if m.fn == nil {
+		return true
+	}
+	fnType := reflect.TypeOf(m.fn)
+	if fnType.NumIn() == 0 {
+		return false
+	}
+	if m.workflow {
+		return fnType.In(0) == reflect.TypeOf((*workflow.Context)(nil)).Elem()
+	}
+	return fnType.In(0) == reflect.TypeOf((*context.Context)(nil)).Elem()
+}
+
+// registry lets registrations register with the environment like with a worker and keeps the
+// functions registered by name for mocks
+type registry struct {
+	worker.Registry
+	env *Env
+}
+
+func (r *registry) RegisterWorkflow(w interface{}) {
+	r.env.workflows[temporalname.Func(w)] = w
+	r.Registry.RegisterWorkflow(w)
+}
+
+func (r *registry) RegisterWorkflowWithOptions(w interface{}, options workflow.RegisterOptions) {
+	name := options.Name
+	if name == "" {
+		name = temporalname.Func(w)
+	}
+	r.env.workflows[name] = w
+	r.Registry.RegisterWorkflowWithOptions(w, options)
+}
+
+func (r *registry) RegisterActivity(a interface{}) {
+	r.recordActivity(a, activity.RegisterOptions{})
+	r.Registry.RegisterActivity(a)
+}
+
+func (r *registry) RegisterActivityWithOptions(a interface{}, options activity.RegisterOptions) {
+	r.recordActivity(a, options)
+	r.Registry.RegisterActivityWithOptions(a, options)
+}
+
+// recordActivity keeps the activity under the name the sdk registers it with. The exported
+// methods of a struct are activities named after the method, prefixed with the name option.
+func (r *registry) recordActivity(a interface{}, options activity.RegisterOptions) {
+	v := reflect.ValueOf(a)
+	if v.Kind() == reflect.Func {
+		name := options.Name
+		if name == "" {
+			name = temporalname.Func(a)
+		}
+		r.env.activities[name] = a
+		return
+	}
+	for i := 0; i < v.NumMethod(); i++ {
+		if method := v.Type().Method(i); method.IsExported() {
+			r.env.activities[options.Name+method.Name] = v.Method(i).Interface()
+		}
+	}
+}
+
+func (r *registry) Start() error                             { return nil }
+func (r *registry) Run(interruptCh <-chan interface{}) error { return nil }
+func (r *registry) Stop()                                    {}
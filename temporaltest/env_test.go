@@ -0,0 +1,152 @@
+package temporaltest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/ConradKurth/gokit/temporaltyped"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+func charge(ctx context.Context, amount int) (string, error) {
+	logger.GetLogger(ctx).InfoCtx(ctx, "charging")
+	return "real", nil
+}
+
+func notify(ctx workflow.Context, receipt string) (bool, error) {
+	return true, nil
+}
+
+var (
+	chargeActivity = temporaltyped.NewActivity("charge", charge)
+	notifyWorkflow = temporaltyped.NewWorkflow("notify", notify)
+	approve        = temporaltyped.NewSignal[bool]("approve")
+	status         = temporaltyped.NewQuery[string]("status")
+	orderWorkflow  = temporaltyped.NewWorkflow("order", order)
+)
+
+func order(ctx workflow.Context, amount int) (string, error) {
+	state := "waiting"
+	if err := status.SetHandler(ctx, func() (string, error) { return state, nil }); err != nil {
+		return "", err
+	}
+	if ok, _ := approve.Receive(ctx); !ok {
+		return "", errors.New("rejected")
+	}
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 1},
+	})
+	receipt, err := chargeActivity.Execute(ctx, amount).Get(ctx)
+	if err != nil {
+		return "", err
+	}
+	state = "charged"
+	if _, err := notifyWorkflow.ExecuteChild(ctx, receipt).Get(ctx); err != nil {
+		return "", err
+	}
+	return receipt, nil
+}
+
+func Test_Env(t *testing.T) {
+	env := NewEnv(t, WithConfig(map[string]interface{}{"currency": "usd"}))
+	env.Register(orderWorkflow, chargeActivity, notifyWorkflow)
+	assert.Equal(t, "usd", env.Config.GetString("currency"))
+
+	env.Activity("charge").With(10).Returns("receipt").Once()
+	env.ChildWorkflow("notify").Returns(true)
+	env.RegisterDelayedCallback(func() {
+		env.AssertQuery("status", "waiting")
+	}, time.Second)
+	env.SignalAfter(time.Minute, "approve", true)
+
+	env.Execute("order", 10)
+
+	var receipt string
+	env.RequireResult(&receipt)
+	assert.Equal(t, "receipt", receipt)
+	assert.Equal(t, []string{"notify"}, env.ChildWorkflows())
+	env.AssertChildStarted("notify")
+	env.AssertQuery("status", "charged")
+	assert.NotEmpty(t, env.Logs.FilterMessage("Workflow completed").All())
+}
+
+func Test_Env_Fails(t *testing.T) {
+	env := NewEnv(t)
+	env.Register(orderWorkflow, chargeActivity, notifyWorkflow)
+
+	env.Activity(charge).Fails(errors.New("declined"))
+	env.ChildWorkflow(notify).Returns(true).Never()
+	env.SignalAfter(time.Second, "approve", true)
+
+	env.Execute("order", 10)
+	require.Error(t, env.GetWorkflowError())
+	assert.Contains(t, env.GetWorkflowError().Error(), "declined")
+	assert.Empty(t, env.ChildWorkflows())
+}
+
+func Test_Env_ActivityLogger(t *testing.T) {
+	env := NewEnv(t)
+	env.Register(orderWorkflow, chargeActivity, notifyWorkflow)
+
+	env.ChildWorkflow("notify").With("real").Do(func(ctx workflow.Context, receipt string) (bool, error) {
+		return true, nil
+	})
+	env.SignalAfter(time.Second, "approve", true)
+
+	env.Execute("order", 10)
+
+	var receipt string
+	env.RequireResult(&receipt)
+	assert.Equal(t, "real", receipt)
+	assert.Len(t, env.Logs.FilterMessage("charging").All(), 1)
+}
+
+type payments struct{}
+
+func (p *payments) Refund(ctx context.Context, receipt string) (bool, error) {
+	return false, errors.New("real")
+}
+
+func refundOrder(ctx workflow.Context, receipt string) (bool, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+	var refunded bool
+	err := workflow.ExecuteActivity(ctx, "Refund", receipt).Get(ctx, &refunded)
+	return refunded, err
+}
+
+// sdkRegistration registers with the default names of the sdk
+type sdkRegistration struct{}
+
+func (sdkRegistration) RegisterWithWorker(w worker.Worker) {
+	w.RegisterWorkflow(refundOrder)
+	w.RegisterActivity(&payments{})
+}
+
+func Test_Env_DefaultNames(t *testing.T) {
+	env := NewEnv(t)
+	env.Register(sdkRegistration{})
+
+	env.Activity("Refund").With("receipt").Returns(true)
+	env.Execute("refundOrder", "receipt")
+
+	var refunded bool
+	env.RequireResult(&refunded)
+	assert.True(t, refunded)
+
+	env = NewEnv(t)
+	env.Register(sdkRegistration{})
+
+	env.Activity("Refund").Fails(errors.New("declined"))
+	env.Execute("refundOrder", "receipt")
+	require.Error(t, env.GetWorkflowError())
+	assert.Contains(t, env.GetWorkflowError().Error(), "declined")
+}
@@ -0,0 +1,213 @@
+package temporaltest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ConradKurth/gokit/logger"
+	"github.com/ConradKurth/gokit/service"
+	"github.com/ConradKurth/gokit/temporalctx"
+	"github.com/nexus-rpc/sdk-go/nexus"
+	"go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/temporalproto"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+type replayOptions struct {
+	dataConverter converter.DataConverter
+	contextFields []temporalctx.Field
+	interceptors  []interceptor.WorkerInterceptor
+}
+
+// WithReplayDataConverter sets the data converter of the histories, e.g. when the payloads
+// are encrypted
+func WithReplayDataConverter(dc converter.DataConverter) func(*replayOptions) {
+	return func(o *replayOptions) {
+		o.dataConverter = dc
+	}
+}
+
+// WithReplayContextFields propagates the fields in addition to the default ones, like the
+// service does with WithTemporalContextFields
+func WithReplayContextFields(fields ...temporalctx.Field) func(*replayOptions) {
+	return func(o *replayOptions) {
+		o.contextFields = append(o.contextFields, fields...)
+	}
+}
+
+// WithReplayInterceptors adds interceptors, they run before the interceptors of the service
+// worker like the interceptors of the client
+func WithReplayInterceptors(interceptors ...interceptor.WorkerInterceptor) func(*replayOptions) {
+	return func(o *replayOptions) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// Replayer replays workflow histories against the current code of the workflows to find
+// changes that are not deterministic
+type Replayer struct {
+	replayer worker.WorkflowReplayer
+}
+
+// NewReplayer returns a replayer of the workflows of the registrations, activities are not
+// needed to replay and ignored. The workflows run with the context propagator and the
+// interceptors of the service worker.
+func NewReplayer(registrations []service.WorkerRegistration, opts ...func(*replayOptions)) (*Replayer, error) {
+	o := replayOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	log := logger.NewWithLogger(zap.NewNop())
+	r, err := worker.NewWorkflowReplayerWithOptions(worker.WorkflowReplayerOptions{
+		DataConverter:      o.dataConverter,
+		ContextPropagators: []workflow.ContextPropagator{temporalctx.NewContextPropagator(o.contextFields...)},
+		Interceptors:       append(o.interceptors, service.WorkerInterceptors(log)...),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating replayer: %w", err)
+	}
+	for _, reg := range registrations {
+		reg.RegisterWithWorker(&replayRegistry{replayer: r})
+	}
+	return &Replayer{replayer: r}, nil
+}
+
+// ReplayFile replays the JSON history in the file, as exported by ExportHistory or the
+// temporal cli
+func (r *Replayer) ReplayFile(path string) error {
+	log := logger.NewLoggerAdapter(logger.NewWithLogger(zap.NewNop()))
+	if err := r.replayer.ReplayWorkflowHistoryFromJSONFile(log, path); err != nil {
+		return fmt.Errorf("replaying %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReplayDir replays every JSON history in the directory and returns the errors of the
+// histories that broke by file
+func (r *Replayer) ReplayDir(dir string) (map[string]error, error) {
+	files, err := historyFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	broken := map[string]error{}
+	for _, f := range files {
+		if err := r.ReplayFile(f); err != nil {
+			broken[f] = err
+		}
+	}
+	return broken, nil
+}
+
+// Test replays the histories in the files and directories in a subtest each, so a broken
+// history fails the test
+func (r *Replayer) Test(t *testing.T, paths ...string) {
+	for _, p := range paths {
+		files := []string{p}
+		if info, err := os.Stat(p); err != nil {
+			t.Fatalf("reading %s: %v", p, err)
+		} else if info.IsDir() {
+			if files, err = historyFiles(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, f := range files {
+			t.Run(filepath.Base(f), func(t *testing.T) {
+				if err := r.ReplayFile(f); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+func historyFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ExportHistory writes the history of the workflow as JSON to w, an empty run id exports the
+// latest run. The output can be replayed as fixture.
+func ExportHistory(ctx context.Context, c client.Client, workflowID, runID string, w io.Writer) error {
+	history := &historypb.History{}
+	iter := c.GetWorkflowHistory(ctx, workflowID, runID, false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("reading history of %s: %w", workflowID, err)
+		}
+		history.Events = append(history.Events, event)
+	}
+
+	data, err := temporalproto.CustomJSONMarshalOptions{Indent: "  "}.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("encoding history of %s: %w", workflowID, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("writing history of %s: %w", workflowID, err)
+	}
+	return nil
+}
+
+// ExportHistoryFile writes the history of the workflow to the file
+func ExportHistoryFile(ctx context.Context, c client.Client, workflowID, runID, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	if err := ExportHistory(ctx, c, workflowID, runID, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replayRegistry lets registrations register their workflows with the replayer like with a
+// worker
+type replayRegistry struct {
+	replayer worker.WorkflowReplayer
+}
+
+func (r *replayRegistry) RegisterWorkflow(w interface{}) {
+	r.replayer.RegisterWorkflow(w)
+}
+
+func (r *replayRegistry) RegisterWorkflowWithOptions(w interface{}, options workflow.RegisterOptions) {
+	r.replayer.RegisterWorkflowWithOptions(w, options)
+}
+
+func (r *replayRegistry) RegisterActivity(a interface{}) {}
+
+func (r *replayRegistry) RegisterActivityWithOptions(a interface{}, options activity.RegisterOptions) {
+}
+
+func (r *replayRegistry) RegisterNexusService(s *nexus.Service) {}
+
+func (r *replayRegistry) Start() error                             { return nil }
+func (r *replayRegistry) Run(interruptCh <-chan interface{}) error { return nil }
+func (r *replayRegistry) Stop()                                    {}
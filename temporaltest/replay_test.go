@@ -0,0 +1,121 @@
+package temporaltest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ConradKurth/gokit/service"
+	"github.com/ConradKurth/gokit/temporalctx"
+	"github.com/ConradKurth/gokit/temporaltyped"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/workflow"
+)
+
+func sleeper(ctx workflow.Context, _ struct{}) (struct{}, error) {
+	return struct{}{}, workflow.Sleep(ctx, time.Minute)
+}
+
+// changedSleeper runs an activity before sleeping, which breaks running workflows
+func changedSleeper(ctx workflow.Context, _ struct{}) (struct{}, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+	if _, err := chargeActivity.Execute(ctx, 1).Get(ctx); err != nil {
+		return struct{}{}, err
+	}
+	return struct{}{}, workflow.Sleep(ctx, time.Minute)
+}
+
+// regionSleeper only sleeps in workflows started in the eu
+func regionSleeper(ctx workflow.Context, _ struct{}) (struct{}, error) {
+	if region, _ := temporalctx.Value(ctx, temporalctx.KeyRegion); region != "eu" {
+		return struct{}{}, nil
+	}
+	return struct{}{}, workflow.Sleep(ctx, time.Minute)
+}
+
+func Test_Replayer(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow temporaltyped.Workflow[struct{}, struct{}]
+		broken   bool
+	}{
+		{
+			name:     "deterministic",
+			workflow: temporaltyped.NewWorkflow("sleeper", sleeper),
+		},
+		{
+			name:     "changed",
+			workflow: temporaltyped.NewWorkflow("sleeper", changedSleeper),
+			broken:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReplayer([]service.WorkerRegistration{tt.workflow, chargeActivity})
+			require.NoError(t, err)
+
+			broken, err := r.ReplayDir("testdata")
+			require.NoError(t, err)
+			if !tt.broken {
+				assert.Empty(t, broken)
+				r.Test(t, "testdata")
+				return
+			}
+			require.Contains(t, broken, filepath.Join("testdata", "sleeper.json"))
+			assert.Contains(t, broken[filepath.Join("testdata", "sleeper.json")].Error(), "nondeterministic")
+		})
+	}
+}
+
+func Test_Replayer_ContextPropagator(t *testing.T) {
+	r, err := NewReplayer([]service.WorkerRegistration{temporaltyped.NewWorkflow("regionSleeper", regionSleeper)})
+	require.NoError(t, err)
+	assert.NoError(t, r.ReplayFile(filepath.Join("testdata", "context", "region_sleeper.json")))
+}
+
+type historyIterator struct {
+	events []*historypb.HistoryEvent
+}
+
+func (h *historyIterator) HasNext() bool {
+	return len(h.events) > 0
+}
+
+func (h *historyIterator) Next() (*historypb.HistoryEvent, error) {
+	event := h.events[0]
+	h.events = h.events[1:]
+	return event, nil
+}
+
+func Test_ExportHistory(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "sleeper.json"))
+	require.NoError(t, err)
+	defer f.Close()
+	history, err := client.HistoryFromJSON(f, client.HistoryJSONOptions{})
+	require.NoError(t, err)
+
+	c := &MockClient{}
+	c.On("GetWorkflowHistory", mock.Anything, "sleeper-1", "", false, mock.Anything).
+		Return(&historyIterator{events: history.Events})
+
+	path := filepath.Join(t.TempDir(), "sleeper-1.json")
+	require.NoError(t, ExportHistoryFile(context.Background(), c, "sleeper-1", "", path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	exported, err := client.HistoryFromJSON(bytes.NewReader(data), client.HistoryJSONOptions{})
+	require.NoError(t, err)
+	assert.Len(t, exported.Events, len(history.Events))
+
+	r, err := NewReplayer([]service.WorkerRegistration{temporaltyped.NewWorkflow("sleeper", sleeper)})
+	require.NoError(t, err)
+	assert.NoError(t, r.ReplayFile(path))
+}
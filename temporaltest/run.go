@@ -5,8 +5,11 @@ import (
 	"reflect"
 
 	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/client"
 )
 
+var _ client.WorkflowRun = (*MockRun)(nil)
+
 type MockRun struct {
 	mock.Mock
 
@@ -25,15 +28,25 @@ func (m *MockRun) GetRunID() string {
 
 func (m *MockRun) Get(ctx context.Context, valuePtr interface{}) error {
 	args := m.Called(ctx, valuePtr)
+	m.setValue(valuePtr)
+	return args.Error(0)
+}
 
-	if valuePtr != nil && m.Value != nil {
-		elem := reflect.ValueOf(valuePtr).Elem()
-		if reflect.ValueOf(m.Value).Kind() == reflect.Ptr {
-			elem.Set(reflect.ValueOf(m.Value).Elem())
-		} else {
-			elem.Set(reflect.ValueOf(m.Value))
-		}
-	}
-
+func (m *MockRun) GetWithOptions(ctx context.Context, valuePtr interface{}, options client.WorkflowRunGetOptions) error {
+	args := m.Called(ctx, valuePtr, options)
+	m.setValue(valuePtr)
 	return args.Error(0)
 }
+
+// setValue copies Value into the result pointer
+func (m *MockRun) setValue(valuePtr interface{}) {
+	if valuePtr == nil || m.Value == nil {
+		return
+	}
+	elem := reflect.ValueOf(valuePtr).Elem()
+	if reflect.ValueOf(m.Value).Kind() == reflect.Ptr {
+		elem.Set(reflect.ValueOf(m.Value).Elem())
+	} else {
+		elem.Set(reflect.ValueOf(m.Value))
+	}
+}
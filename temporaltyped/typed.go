@@ -0,0 +1,292 @@
+package temporaltyped
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+
+	"github.com/ConradKurth/gokit/internal/temporalname"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Workflow is a workflow with typed input and output. It is registered and started under the
+// same name, so the worker and its callers always agree on the name and the types.
+type Workflow[In, Out any] struct {
+	name      string
+	taskQueue string
+	fn        func(workflow.Context, In) (Out, error)
+}
+
+// NewWorkflow returns a typed workflow registered and started under the name
+func NewWorkflow[In, Out any](name string, fn func(workflow.Context, In) (Out, error)) Workflow[In, Out] {
+	return Workflow[In, Out]{name: name, fn: fn}
+}
+
+// WorkflowFromFunc returns a typed workflow named after the function, like the sdk does when
+// registering a function. Anonymous functions have no stable name and need NewWorkflow.
+func WorkflowFromFunc[In, Out any](fn func(workflow.Context, In) (Out, error)) (Workflow[In, Out], error) {
+	name, err := funcName(fn)
+	if err != nil {
+		return Workflow[In, Out]{}, err
+	}
+	return NewWorkflow(name, fn), nil
+}
+
+// OnTaskQueue returns the workflow registered with the worker of the task queue and started
+// on it when the start options have no task queue
+func (w Workflow[In, Out]) OnTaskQueue(taskQueue string) Workflow[In, Out] {
+	w.taskQueue = taskQueue
+	return w
+}
+
+// Name is the workflow type
+func (w Workflow[In, Out]) Name() string {
+	return w.name
+}
+
+// TaskQueue implements service.TaskQueueSelector
+func (w Workflow[In, Out]) TaskQueue() string {
+	return w.taskQueue
+}
+
+// RegisterWithWorker implements service.WorkerRegistration
+func (w Workflow[In, Out]) RegisterWithWorker(r worker.Worker) {
+	w.Register(r)
+}
+
+// Register registers the workflow under its name, e.g. with a test environment
+func (w Workflow[In, Out]) Register(r worker.WorkflowRegistry) {
+	r.RegisterWorkflowWithOptions(w.fn, workflow.RegisterOptions{Name: w.name})
+}
+
+// Start starts the workflow without waiting for its result
+func (w Workflow[In, Out]) Start(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in In) (Run[Out], error) {
+	if opts.TaskQueue == "" {
+		opts.TaskQueue = w.taskQueue
+	}
+	run, err := c.ExecuteWorkflow(ctx, opts, w.name, in)
+	if err != nil {
+		return Run[Out]{}, fmt.Errorf("starting workflow %s: %w", w.name, err)
+	}
+	return Run[Out]{WorkflowRun: run}, nil
+}
+
+// Execute starts the workflow and waits for its result
+func (w Workflow[In, Out]) Execute(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in In) (Out, error) {
+	run, err := w.Start(ctx, c, opts, in)
+	if err != nil {
+		var out Out
+		return out, err
+	}
+	return run.Get(ctx)
+}
+
+// ExecuteChild starts the workflow as child of the current workflow
+func (w Workflow[In, Out]) ExecuteChild(ctx workflow.Context, in In) Future[Out] {
+	if w.taskQueue != "" && workflow.GetChildWorkflowOptions(ctx).TaskQueue == "" {
+		opts := workflow.GetChildWorkflowOptions(ctx)
+		opts.TaskQueue = w.taskQueue
+		ctx = workflow.WithChildOptions(ctx, opts)
+	}
+	return Future[Out]{Future: workflow.ExecuteChildWorkflow(ctx, w.name, in)}
+}
+
+// Run is a started workflow with a typed result
+type Run[Out any] struct {
+	client.WorkflowRun
+}
+
+// Get waits for the result of the workflow
+func (r Run[Out]) Get(ctx context.Context) (Out, error) {
+	var out Out
+	err := r.WorkflowRun.Get(ctx, &out)
+	return out, err
+}
+
+// Activity is an activity with typed input and output
+type Activity[In, Out any] struct {
+	name      string
+	taskQueue string
+	fn        func(context.Context, In) (Out, error)
+}
+
+// NewActivity returns a typed activity registered and scheduled under the name
+func NewActivity[In, Out any](name string, fn func(context.Context, In) (Out, error)) Activity[In, Out] {
+	return Activity[In, Out]{name: name, fn: fn}
+}
+
+// ActivityFromFunc returns a typed activity named after the function, like the sdk does when
+// registering a function. Anonymous functions have no stable name and need NewActivity.
+func ActivityFromFunc[In, Out any](fn func(context.Context, In) (Out, error)) (Activity[In, Out], error) {
+	name, err := funcName(fn)
+	if err != nil {
+		return Activity[In, Out]{}, err
+	}
+	return NewActivity(name, fn), nil
+}
+
+// OnTaskQueue returns the activity registered with the worker of the task queue and scheduled
+// on it when the activity options have no task queue
+func (a Activity[In, Out]) OnTaskQueue(taskQueue string) Activity[In, Out] {
+	a.taskQueue = taskQueue
+	return a
+}
+
+// Name is the activity type
+func (a Activity[In, Out]) Name() string {
+	return a.name
+}
+
+// TaskQueue implements service.TaskQueueSelector
+func (a Activity[In, Out]) TaskQueue() string {
+	return a.taskQueue
+}
+
+// RegisterWithWorker implements service.WorkerRegistration
+func (a Activity[In, Out]) RegisterWithWorker(r worker.Worker) {
+	a.Register(r)
+}
+
+// Register registers the activity under its name, e.g. with a test environment
+func (a Activity[In, Out]) Register(r worker.ActivityRegistry) {
+	r.RegisterActivityWithOptions(a.fn, activity.RegisterOptions{Name: a.name})
+}
+
+// Execute schedules the activity with the activity options of the context
+func (a Activity[In, Out]) Execute(ctx workflow.Context, in In) Future[Out] {
+	if a.taskQueue != "" && workflow.GetActivityOptions(ctx).TaskQueue == "" {
+		opts := workflow.GetActivityOptions(ctx)
+		opts.TaskQueue = a.taskQueue
+		ctx = workflow.WithActivityOptions(ctx, opts)
+	}
+	return Future[Out]{Future: workflow.ExecuteActivity(ctx, a.name, in)}
+}
+
+// ExecuteLocal runs the activity as local activity with the local activity options of the context
+func (a Activity[In, Out]) ExecuteLocal(ctx workflow.Context, in In) Future[Out] {
+	return Future[Out]{Future: workflow.ExecuteLocalActivity(ctx, a.fn, in)}
+}
+
+// Future is the typed result of an activity or child workflow
+type Future[Out any] struct {
+	workflow.Future
+}
+
+// Get waits for the result
+func (f Future[Out]) Get(ctx workflow.Context) (Out, error) {
+	var out Out
+	err := f.Future.Get(ctx, &out)
+	return out, err
+}
+
+// Signal is a signal with a typed value
+type Signal[T any] struct {
+	name string
+}
+
+// NewSignal returns a typed signal
+func NewSignal[T any](name string) Signal[T] {
+	return Signal[T]{name: name}
+}
+
+// Name is the signal name
+func (s Signal[T]) Name() string {
+	return s.name
+}
+
+// Send signals the workflow, an empty run id signals the current run
+func (s Signal[T]) Send(ctx context.Context, c client.Client, workflowID, runID string, value T) error {
+	if err := c.SignalWorkflow(ctx, workflowID, runID, s.name, value); err != nil {
+		return fmt.Errorf("signaling %s: %w", s.name, err)
+	}
+	return nil
+}
+
+// SendWithStart signals the workflow and starts it if it is not running
+func SendWithStart[T, In, Out any](ctx context.Context, c client.Client, s Signal[T], value T, w Workflow[In, Out], opts client.StartWorkflowOptions, in In) (Run[Out], error) {
+	if opts.TaskQueue == "" {
+		opts.TaskQueue = w.taskQueue
+	}
+	run, err := c.SignalWithStartWorkflow(ctx, opts.ID, s.name, value, opts, w.name, in)
+	if err != nil {
+		return Run[Out]{}, fmt.Errorf("signaling %s with start of %s: %w", s.name, w.name, err)
+	}
+	return Run[Out]{WorkflowRun: run}, nil
+}
+
+// SendExternal signals another workflow from a workflow
+func (s Signal[T]) SendExternal(ctx workflow.Context, workflowID, runID string, value T) workflow.Future {
+	return workflow.SignalExternalWorkflow(ctx, workflowID, runID, s.name, value)
+}
+
+// Receive waits for the next signal in a workflow
+func (s Signal[T]) Receive(ctx workflow.Context) (T, bool) {
+	var value T
+	more := workflow.GetSignalChannel(ctx, s.name).Receive(ctx, &value)
+	return value, more
+}
+
+// ReceiveAsync returns the next signal if one was received
+func (s Signal[T]) ReceiveAsync(ctx workflow.Context) (T, bool) {
+	var value T
+	ok := workflow.GetSignalChannel(ctx, s.name).ReceiveAsync(&value)
+	return value, ok
+}
+
+// AddToSelector calls fn with every signal the selector receives
+func (s Signal[T]) AddToSelector(ctx workflow.Context, selector workflow.Selector, fn func(T)) workflow.Selector {
+	return selector.AddReceive(workflow.GetSignalChannel(ctx, s.name), func(c workflow.ReceiveChannel, _ bool) {
+		var value T
+		c.Receive(ctx, &value)
+		fn(value)
+	})
+}
+
+// Query is a query with a typed result
+type Query[T any] struct {
+	name string
+}
+
+// NewQuery returns a typed query
+func NewQuery[T any](name string) Query[T] {
+	return Query[T]{name: name}
+}
+
+// Name is the query type
+func (q Query[T]) Name() string {
+	return q.name
+}
+
+// SetHandler answers the query in the workflow
+func (q Query[T]) SetHandler(ctx workflow.Context, fn func() (T, error)) error {
+	return workflow.SetQueryHandler(ctx, q.name, fn)
+}
+
+// Query asks the workflow, an empty run id queries the current run
+func (q Query[T]) Query(ctx context.Context, c client.Client, workflowID, runID string) (T, error) {
+	var value T
+	encoded, err := c.QueryWorkflow(ctx, workflowID, runID, q.name)
+	if err != nil {
+		return value, fmt.Errorf("querying %s: %w", q.name, err)
+	}
+	if err := encoded.Get(&value); err != nil {
+		return value, fmt.Errorf("decoding %s: %w", q.name, err)
+	}
+	return value, nil
+}
+
+// funcName returns the name the sdk registers a function with
+func funcName(fn interface{}) (string, error) {
+	name := temporalname.Func(fn)
+	if anonymous.MatchString(name) {
+		return "", fmt.Errorf("temporaltyped: %s is anonymous and needs a name", runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name())
+	}
+	return name, nil
+}
+
+var anonymous = regexp.MustCompile(`^(func)?\d+$`)
@@ -0,0 +1,119 @@
+package temporaltyped
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ConradKurth/gokit/temporaltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+type Order struct {
+	ID    string
+	Items int
+}
+
+type Receipt struct {
+	OrderID string
+	Total   int
+}
+
+func PriceOrder(_ context.Context, o Order) (int, error) {
+	return o.Items * 10, nil
+}
+
+var (
+	priceActivity = NewActivity("PriceOrder", PriceOrder)
+	discount      = NewSignal[int]("discount")
+	status        = NewQuery[string]("status")
+	orderWorkflow = NewWorkflow("OrderWorkflow", OrderWorkflow)
+)
+
+func OrderWorkflow(ctx workflow.Context, o Order) (Receipt, error) {
+	state := "pricing"
+	if err := status.SetHandler(ctx, func() (string, error) { return state, nil }); err != nil {
+		return Receipt{}, err
+	}
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+	total, err := priceActivity.Execute(ctx, o).Get(ctx)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	state = "waiting for discount"
+	off, _ := discount.Receive(ctx)
+	return Receipt{OrderID: o.ID, Total: total - off}, nil
+}
+
+func TestWorkflow(t *testing.T) {
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestWorkflowEnvironment()
+	orderWorkflow.Register(env)
+	priceActivity.Register(env)
+
+	env.RegisterDelayedCallback(func() {
+		v, err := env.QueryWorkflow(status.Name())
+		require.NoError(t, err)
+		var state string
+		require.NoError(t, v.Get(&state))
+		assert.Equal(t, "waiting for discount", state)
+
+		env.SignalWorkflow(discount.Name(), 5)
+	}, time.Second)
+
+	env.ExecuteWorkflow(orderWorkflow.Name(), Order{ID: "o-1", Items: 3})
+	require.NoError(t, env.GetWorkflowError())
+
+	var r Receipt
+	require.NoError(t, env.GetWorkflowResult(&r))
+	assert.Equal(t, Receipt{OrderID: "o-1", Total: 25}, r)
+}
+
+type receiptRun struct {
+	client.WorkflowRun
+	receipt Receipt
+}
+
+func (r receiptRun) Get(_ context.Context, valuePtr interface{}) error {
+	*valuePtr.(*Receipt) = r.receipt
+	return nil
+}
+
+func TestWorkflow_Client(t *testing.T) {
+	ctx := context.Background()
+	c := &temporaltest.MockClient{}
+	run := receiptRun{receipt: Receipt{OrderID: "o-1", Total: 30}}
+
+	w := orderWorkflow.OnTaskQueue("orders")
+	c.On("ExecuteWorkflow", ctx, client.StartWorkflowOptions{ID: "o-1", TaskQueue: "orders"}, "OrderWorkflow", []interface{}{Order{ID: "o-1", Items: 3}}).
+		Return(run, nil)
+
+	r, err := w.Execute(ctx, c, client.StartWorkflowOptions{ID: "o-1"}, Order{ID: "o-1", Items: 3})
+	require.NoError(t, err)
+	assert.Equal(t, Receipt{OrderID: "o-1", Total: 30}, r)
+
+	c.On("SignalWorkflow", ctx, "o-1", "", "discount", 5).Return(fmt.Errorf("not found"))
+	assert.EqualError(t, discount.Send(ctx, c, "o-1", "", 5), "signaling discount: not found")
+	c.AssertExpectations(t)
+}
+
+func TestFromFunc(t *testing.T) {
+	w, err := WorkflowFromFunc(OrderWorkflow)
+	require.NoError(t, err)
+	assert.Equal(t, "OrderWorkflow", w.Name())
+	a, err := ActivityFromFunc(PriceOrder)
+	require.NoError(t, err)
+	assert.Equal(t, "PriceOrder", a.Name())
+
+	_, err = ActivityFromFunc(func(context.Context, int) (int, error) { return 0, nil })
+	assert.ErrorContains(t, err, "is anonymous and needs a name")
+	_, err = WorkflowFromFunc(func(workflow.Context, int) (int, error) { return 0, nil })
+	assert.ErrorContains(t, err, "is anonymous and needs a name")
+}
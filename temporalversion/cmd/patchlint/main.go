@@ -0,0 +1,36 @@
+// Command patchlint lists the workflow patches in the code, so old ones can be deprecated and
+// removed. It takes the directories to check, like ./..., and defaults to the current one.
+//
+//	go run github.com/ConradKurth/gokit/temporalversion/cmd/patchlint ./...
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ConradKurth/gokit/temporalversion"
+)
+
+func main() {
+	dirs := os.Args[1:]
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	for i, dir := range dirs {
+		// directories are always checked recursively
+		dirs[i] = strings.TrimSuffix(strings.TrimSuffix(dir, "..."), "/")
+		if dirs[i] == "" {
+			dirs[i] = "."
+		}
+	}
+
+	patches, err := temporalversion.Lint(dirs...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, p := range patches {
+		fmt.Println(p)
+	}
+}
@@ -0,0 +1,320 @@
+package temporalversion
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	workflowPackage = "go.temporal.io/sdk/workflow"
+	versionPackage  = "github.com/ConradKurth/gokit/temporalversion"
+)
+
+// States of a patch
+const (
+	// StatePatched patches still run the old code for workflows started before the change
+	StatePatched = "patched"
+	// StateDeprecated patches only keep the marker, the call can be removed once no running
+	// workflow has it
+	StateDeprecated = "deprecated"
+)
+
+// Patch is a call of workflow.GetVersion, of a helper of this package or of a wrapper of them,
+// in the code
+type Patch struct {
+	ChangeID string
+	State    string
+	Position token.Position
+}
+
+func (p Patch) String() string {
+	return fmt.Sprintf("%s: %s (%s)", p.Position, p.ChangeID, p.State)
+}
+
+// Lint lists the patches in the go files of the directories and their sub directories. Test
+// files, vendor and testdata are skipped. Functions passing one of their parameters as the
+// change id are wrappers, the calls of them are listed instead. Wrappers in other packages
+// are only found when their package is linted too.
+func Lint(dirs ...string) ([]Patch, error) {
+	fset := token.NewFileSet()
+	paths := packagePaths{}
+	var calls []patchCall
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				name := d.Name()
+				if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return nil
+			}
+
+			f, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return fmt.Errorf("parsing %s: %w", path, err)
+			}
+			calls = append(calls, fileCalls(f, paths.get(filepath.Dir(path)))...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("linting %s: %w", dir, err)
+		}
+	}
+
+	patches := findPatches(fset, calls)
+	sort.Slice(patches, func(i, j int) bool {
+		if patches[i].Position.Filename != patches[j].Position.Filename {
+			return patches[i].Position.Filename < patches[j].Position.Filename
+		}
+		return patches[i].Position.Line < patches[j].Position.Line
+	})
+	return patches, nil
+}
+
+// wrapper is a function passing one of its parameters as the change id of a patch
+type wrapper struct {
+	param int
+	state string
+}
+
+// patchCall is a call of a function that might be a patch
+type patchCall struct {
+	call *ast.CallExpr
+	// target is the package path and name of the called function
+	target string
+	// decl is the function the call is in, it is nil for methods and package variables
+	decl *ast.FuncDecl
+	// fn is the package path and name of decl
+	fn string
+}
+
+// patch returns the state and change id of the call when it calls a patch or wrapper
+func (c patchCall) patch(fset *token.FileSet, wrappers map[string]wrapper) (string, ast.Expr, bool) {
+	if c.target == workflowPackage+".GetVersion" {
+		if len(c.call.Args) != 4 {
+			return "", nil, false
+		}
+		if exprString(fset, c.call.Args[2]) == exprString(fset, c.call.Args[3]) {
+			return StateDeprecated, c.call.Args[1], true
+		}
+		return StatePatched, c.call.Args[1], true
+	}
+	w, ok := wrappers[c.target]
+	if !ok || w.param >= len(c.call.Args) {
+		return "", nil, false
+	}
+	return w.state, c.call.Args[w.param], true
+}
+
+func findPatches(fset *token.FileSet, calls []patchCall) []Patch {
+	wrappers := map[string]wrapper{
+		versionPackage + ".Patched":   {param: 1, state: StatePatched},
+		versionPackage + ".Version":   {param: 1, state: StatePatched},
+		versionPackage + ".Deprecate": {param: 1, state: StateDeprecated},
+	}
+	// a wrapper of a wrapper is only found once the wrapper it calls is known
+	for changed := true; changed; {
+		changed = false
+		for _, c := range calls {
+			if c.decl == nil {
+				continue
+			}
+			if _, ok := wrappers[c.fn]; ok {
+				continue
+			}
+			state, changeID, ok := c.patch(fset, wrappers)
+			if !ok {
+				continue
+			}
+			if i, ok := paramIndex(c.decl, changeID); ok {
+				wrappers[c.fn] = wrapper{param: i, state: state}
+				changed = true
+			}
+		}
+	}
+
+	var patches []Patch
+	for _, c := range calls {
+		state, arg, ok := c.patch(fset, wrappers)
+		if !ok {
+			continue
+		}
+		changeID, ok := constString(fset, arg)
+		if !ok {
+			continue
+		}
+		patches = append(patches, Patch{ChangeID: changeID, State: state, Position: fset.Position(c.call.Pos())})
+	}
+	return patches
+}
+
+// fileCalls returns the calls of functions in the file of the package
+func fileCalls(f *ast.File, pkg string) []patchCall {
+	imports := map[string]string{}
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		imports[importName(f, path)] = path
+	}
+
+	var calls []patchCall
+	for _, d := range f.Decls {
+		fd, _ := d.(*ast.FuncDecl)
+		if fd != nil && fd.Recv != nil {
+			fd = nil
+		}
+		ast.Inspect(d, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) < 2 {
+				return true
+			}
+
+			var target string
+			switch fun := call.Fun.(type) {
+			case *ast.Ident:
+				// functions of other files of the package are not resolved
+				if fun.Obj == nil || fun.Obj.Kind == ast.Fun {
+					target = pkg + "." + fun.Name
+				}
+			case *ast.SelectorExpr:
+				if x, ok := fun.X.(*ast.Ident); ok && x.Obj == nil && imports[x.Name] != "" {
+					target = imports[x.Name] + "." + fun.Sel.Name
+				}
+			}
+			if target == "" {
+				return true
+			}
+
+			c := patchCall{call: call, target: target}
+			if fd != nil {
+				c.decl, c.fn = fd, pkg+"."+fd.Name.Name
+			}
+			calls = append(calls, c)
+			return true
+		})
+	}
+	return calls
+}
+
+// paramIndex returns the position of the parameter of the function the expression refers to
+func paramIndex(fd *ast.FuncDecl, e ast.Expr) (int, bool) {
+	ident, ok := e.(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return 0, false
+	}
+	i := 0
+	for _, field := range fd.Type.Params.List {
+		for _, name := range field.Names {
+			if ident.Obj.Decl == field && name.Name == ident.Name {
+				return i, true
+			}
+			i++
+		}
+	}
+	return 0, false
+}
+
+// packagePaths caches the import paths of directories
+type packagePaths map[string]string
+
+// get returns the import path of the package in the directory from the go.mod above it, or
+// the directory when there is none
+func (p packagePaths) get(dir string) string {
+	if path, ok := p[dir]; ok {
+		return path
+	}
+
+	path := dir
+	if abs, err := filepath.Abs(dir); err == nil {
+		for root := abs; ; root = filepath.Dir(root) {
+			if module, ok := modulePath(filepath.Join(root, "go.mod")); ok {
+				path = module
+				if rel, _ := filepath.Rel(root, abs); rel != "." {
+					path += "/" + filepath.ToSlash(rel)
+				}
+				break
+			}
+			if filepath.Dir(root) == root {
+				break
+			}
+		}
+	}
+	p[dir] = path
+	return path
+}
+
+func modulePath(file string) (string, bool) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), true
+		}
+	}
+	return "", false
+}
+
+// importName returns the name the package is imported with in the file
+func importName(f *ast.File, path string) string {
+	for _, imp := range f.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p != path {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+	return ""
+}
+
+// constString returns the value of a string literal or constant of the file. Other expressions
+// are returned as written, function parameters are not a change id.
+func constString(fset *token.FileSet, e ast.Expr) (string, bool) {
+	switch v := e.(type) {
+	case *ast.BasicLit:
+		if s, err := strconv.Unquote(v.Value); err == nil {
+			return s, true
+		}
+	case *ast.Ident:
+		if v.Obj == nil {
+			break
+		}
+		switch decl := v.Obj.Decl.(type) {
+		case *ast.Field:
+			return "", false
+		case *ast.ValueSpec:
+			if v.Obj.Kind != ast.Con {
+				break
+			}
+			for i, name := range decl.Names {
+				if name.Name == v.Name && i < len(decl.Values) {
+					return constString(fset, decl.Values[i])
+				}
+			}
+		}
+	}
+	return exprString(fset, e), true
+}
+
+func exprString(fset *token.FileSet, e ast.Expr) string {
+	var b strings.Builder
+	_ = printer.Fprint(&b, fset, e)
+	return b.String()
+}
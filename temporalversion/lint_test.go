@@ -0,0 +1,34 @@
+package temporalversion
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	patches, err := Lint(filepath.Join("testdata", "workflows"))
+	require.NoError(t, err)
+
+	var listed []string
+	for _, p := range patches {
+		listed = append(listed, fmt.Sprintf("%d %s %s", p.Position.Line, p.ChangeID, p.State))
+	}
+	assert.Equal(t, []string{
+		"14 add-fraud-check patched",
+		"17 retry-payment deprecated",
+		"18 split-shipping patched",
+		"21 notify-customer patched",
+		"22 send-invoice deprecated",
+		// calls of wrappers, the wrappers themselves are not listed
+		"23 express-shipping patched",
+		"26 send-reminder patched",
+	}, listed)
+	assert.Equal(t, filepath.Join("testdata", "workflows", "order.go")+":14:5: add-fraud-check (patched)", patches[0].String())
+
+	_, err = Lint("missing")
+	assert.Error(t, err)
+}
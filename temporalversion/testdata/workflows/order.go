@@ -0,0 +1,32 @@
+package workflows
+
+import (
+	"time"
+
+	"github.com/ConradKurth/gokit/temporalversion"
+	"github.com/ConradKurth/gokit/temporalversion/testdata/workflows/versions"
+	wf "go.temporal.io/sdk/workflow"
+)
+
+const fraudCheck = "add-fraud-check"
+
+func Order(ctx wf.Context) error {
+	if temporalversion.Patched(ctx, fraudCheck) {
+		_ = wf.Sleep(ctx, time.Second)
+	}
+	temporalversion.Deprecate(ctx, "retry-payment")
+	switch temporalversion.Version(ctx, "split-shipping", 2) {
+	case wf.DefaultVersion:
+	}
+	wf.GetVersion(ctx, "notify-customer", wf.DefaultVersion, 1)
+	wf.GetVersion(ctx, "send-invoice", 1, 1)
+	if versions.Enabled(ctx, "express-shipping") {
+		_ = wf.Sleep(ctx, time.Second)
+	}
+	return remind(ctx, "send-reminder")
+}
+
+func remind(ctx wf.Context, changeID string) error {
+	wf.GetVersion(ctx, changeID, wf.DefaultVersion, 1)
+	return nil
+}
@@ -0,0 +1,7 @@
+package workflows
+
+import wf "go.temporal.io/sdk/workflow"
+
+func testPatch(ctx wf.Context) {
+	wf.GetVersion(ctx, "test-only", wf.DefaultVersion, 1)
+}
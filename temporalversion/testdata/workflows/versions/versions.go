@@ -0,0 +1,10 @@
+package versions
+
+import (
+	"github.com/ConradKurth/gokit/temporalversion"
+	"go.temporal.io/sdk/workflow"
+)
+
+func Enabled(ctx workflow.Context, changeID string) bool {
+	return temporalversion.Patched(ctx, changeID)
+}
@@ -0,0 +1,31 @@
+package temporalversion
+
+import (
+	"go.temporal.io/sdk/workflow"
+)
+
+// Patches follow the lifecycle of workflow.GetVersion. A change is first added with Patched,
+// which keeps the old code for workflows that started before the change. Once those have
+// finished the old code is removed and Patched is replaced by Deprecate, so workflows started
+// in between still replay. The call is removed once no running workflow has the marker.
+//
+// Change ids are unique within a workflow and describe the change, e.g. "add-fraud-check".
+// Lint lists the patches that are still in the code.
+
+// Patched reports if the workflow runs the new code of the change. It is false for workflows
+// that started before the change.
+func Patched(ctx workflow.Context, changeID string) bool {
+	return workflow.GetVersion(ctx, changeID, workflow.DefaultVersion, 1) == 1
+}
+
+// Deprecate keeps the marker of a change whose old code was removed. Replaying a workflow
+// that started before the change fails, so it must only replace Patched once those finished.
+func Deprecate(ctx workflow.Context, changeID string) {
+	workflow.GetVersion(ctx, changeID, 1, 1)
+}
+
+// Version returns the version of a change with several versions, from workflow.DefaultVersion
+// for workflows that started before the first one up to max
+func Version(ctx workflow.Context, changeID string, max workflow.Version) workflow.Version {
+	return workflow.GetVersion(ctx, changeID, workflow.DefaultVersion, max)
+}
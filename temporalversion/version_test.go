@@ -0,0 +1,52 @@
+package temporalversion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		recorded workflow.Version
+		patched  bool
+		version  workflow.Version
+	}{
+		{
+			name:     "started before the change",
+			recorded: workflow.DefaultVersion,
+			version:  workflow.DefaultVersion,
+		},
+		{
+			name:     "started after the change",
+			recorded: 1,
+			patched:  true,
+			version:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s testsuite.WorkflowTestSuite
+			env := s.NewTestWorkflowEnvironment()
+			env.OnGetVersion("add-fraud-check", workflow.DefaultVersion, 1).Return(tt.recorded)
+			env.OnGetVersion("split-shipping", workflow.DefaultVersion, 2).Return(tt.version)
+
+			var patched bool
+			var version workflow.Version
+			env.ExecuteWorkflow(func(ctx workflow.Context) error {
+				patched = Patched(ctx, "add-fraud-check")
+				version = Version(ctx, "split-shipping", 2)
+				Deprecate(ctx, "retry-payment")
+				return nil
+			})
+			require.NoError(t, env.GetWorkflowError())
+			assert.Equal(t, tt.patched, patched)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ConradKurth/gokit/config"
+)
+
+const (
+	defaultReloadInterval = 30 * time.Second
+	defaultRenewBefore    = 24 * time.Hour
+)
+
+// Options describes the certificates of one side of a connection. Every PEM can be read
+// from a file or passed as a value, files take precedence and are reloaded when they change.
+type Options struct {
+	Enabled bool
+
+	CertFile string
+	KeyFile  string
+	CAFile   string
+
+	Cert string
+	Key  string
+	CA   string
+
+	// ServerName overrides the name the server certificate is verified against
+	ServerName string
+	// ClientAuth requires and verifies client certificates against the CA on servers
+	ClientAuth bool
+	// ReloadInterval is how often the files are checked for changes
+	ReloadInterval time.Duration
+	// RenewBefore is how long before its expiry the certificate is reloaded on every check,
+	// even when the files did not change
+	RenewBefore time.Duration
+	// OnReloadError is called when changed files can not be loaded, the previous
+	// certificates stay in use
+	OnReloadError func(err error)
+}
+
+// FromConfig reads the options below the config path, e.g. `grpc.tls`.
+func FromConfig(c *config.Config, path string) Options {
+	return Options{
+		Enabled:        c.GetBool(path + ".enabled"),
+		CertFile:       c.GetString(path + ".certFile"),
+		KeyFile:        c.GetString(path + ".keyFile"),
+		CAFile:         c.GetString(path + ".caFile"),
+		Cert:           c.GetString(path + ".cert"),
+		Key:            c.GetString(path + ".key"),
+		CA:             c.GetString(path + ".ca"),
+		ServerName:     c.GetString(path + ".serverName"),
+		ClientAuth:     c.GetBool(path + ".clientAuth"),
+		ReloadInterval: time.Duration(c.GetInt(path+".reloadIntervalSecs")) * time.Second,
+		RenewBefore:    time.Duration(c.GetInt(path+".renewBeforeSecs")) * time.Second,
+	}
+}
+
+func (o Options) hasCert() bool {
+	return o.CertFile != "" || o.Cert != ""
+}
+
+func (o Options) hasKey() bool {
+	return o.KeyFile != "" || o.Key != ""
+}
+
+func (o Options) hasCA() bool {
+	return o.CAFile != "" || o.CA != ""
+}
+
+// NewServerConfig returns the tls config of a server. A certificate is required, client
+// certificates are verified against the CA when ClientAuth is set. The config offers h2 and
+// http/1.1 through ALPN.
+func NewServerConfig(o Options) (*tls.Config, error) {
+	if !o.hasCert() {
+		return nil, errors.New("tls: server requires a certificate and key")
+	}
+	if o.ClientAuth && !o.hasCA() {
+		return nil, errors.New("tls: client auth requires a CA")
+	}
+
+	l, err := newLoader(o)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// grpc and http servers only add h2 to their own copy of the config, which the
+		// config of a client auth handshake below is not cloned from
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return l.certificate(), nil
+		},
+	}
+	if o.ClientAuth {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		// a config per handshake so a reloaded CA is used for new connections
+		cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c := cfg.Clone()
+			c.GetConfigForClient = nil
+			c.ClientCAs = l.pool()
+			return c, nil
+		}
+	}
+	return cfg, nil
+}
+
+// NewClientConfig returns the tls config of a client. The server is verified against the CA,
+// or the system roots without one. The certificate is presented when the server asks for it.
+func NewClientConfig(o Options) (*tls.Config, error) {
+	l, err := newLoader(o)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: o.ServerName,
+	}
+	if o.hasCert() {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return l.certificate(), nil
+		}
+	}
+	if o.hasCA() {
+		// the roots of a tls config can not change, so the handshake skips the verification
+		// and it is done here against the current CA instead
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyServer(cs, l.pool())
+		}
+	}
+	return cfg, nil
+}
+
+func verifyServer(cs tls.ConnectionState, roots *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tls: server did not present a certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		return fmt.Errorf("tls: verifying server certificate: %w", err)
+	}
+	return nil
+}
+
+// loader holds the current certificate and CA and reloads them when their files change
+type loader struct {
+	opts Options
+
+	lock     sync.RWMutex
+	cert     *tls.Certificate
+	caPool   *x509.CertPool
+	modTimes map[string]time.Time
+	checked  time.Time
+}
+
+func newLoader(o Options) (*loader, error) {
+	if o.hasCert() != o.hasKey() {
+		return nil, errors.New("tls: certificate and key must be set together")
+	}
+	if o.ReloadInterval == 0 {
+		o.ReloadInterval = defaultReloadInterval
+	}
+	if o.RenewBefore == 0 {
+		o.RenewBefore = defaultRenewBefore
+	}
+
+	l := &loader{opts: o}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *loader) certificate() *tls.Certificate {
+	l.reloadIfChanged()
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.cert
+}
+
+func (l *loader) pool() *x509.CertPool {
+	l.reloadIfChanged()
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.caPool
+}
+
+func (l *loader) files() []string {
+	var files []string
+	for _, f := range []string{l.opts.CertFile, l.opts.KeyFile, l.opts.CAFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// reloadIfChanged checks the files at most once per reload interval
+func (l *loader) reloadIfChanged() {
+	files := l.files()
+	if len(files) == 0 {
+		return
+	}
+
+	l.lock.Lock()
+	if time.Since(l.checked) < l.opts.ReloadInterval {
+		l.lock.Unlock()
+		return
+	}
+	l.checked = time.Now()
+	// a certificate close to its expiry is reloaded even when the modification time was kept
+	changed := l.cert != nil && time.Until(l.cert.Leaf.NotAfter) < l.opts.RenewBefore
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil || !info.ModTime().Equal(l.modTimes[f]) {
+			changed = true
+			break
+		}
+	}
+	l.lock.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := l.load(); err != nil && l.opts.OnReloadError != nil {
+		l.opts.OnReloadError(err)
+	}
+}
+
+func (l *loader) load() error {
+	modTimes := map[string]time.Time{}
+	for _, f := range l.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		modTimes[f] = info.ModTime()
+	}
+
+	var cert *tls.Certificate
+	if l.opts.hasCert() {
+		certPEM, err := readPEM(l.opts.CertFile, l.opts.Cert)
+		if err != nil {
+			return fmt.Errorf("tls: reading certificate: %w", err)
+		}
+		keyPEM, err := readPEM(l.opts.KeyFile, l.opts.Key)
+		if err != nil {
+			return fmt.Errorf("tls: reading key: %w", err)
+		}
+		// the key can be PKCS#1, PKCS#8 or EC and of type RSA, ECDSA or Ed25519
+		c, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("tls: loading key pair: %w", err)
+		}
+		if time.Now().After(c.Leaf.NotAfter) {
+			return fmt.Errorf("tls: certificate %q expired at %s", c.Leaf.Subject.CommonName, c.Leaf.NotAfter.Format(time.RFC3339))
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if l.opts.hasCA() {
+		caPEM, err := readPEM(l.opts.CAFile, l.opts.CA)
+		if err != nil {
+			return fmt.Errorf("tls: reading CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return errors.New("tls: CA contains no certificates")
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.cert = cert
+	l.caPool = pool
+	l.modTimes = modTimes
+	return nil
+}
+
+func readPEM(file, value string) ([]byte, error) {
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	if value == "" {
+		return nil, errors.New("no file or value set")
+	}
+	return []byte(value), nil
+}
@@ -0,0 +1,304 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+// handshake connects the client to the server and returns the error of each side
+func handshake(t *testing.T, server, client *tls.Config) (error, error) {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), client)
+	if err == nil {
+		// with tls 1.3 the server verifies the client certificate after the client finished
+		// its handshake, a rejection is only seen when reading
+		_, err = conn.Read(make([]byte, 1))
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		conn.Close()
+	}
+	return <-serverErr, err
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	otherCA := newTestCert(t, "other", nil)
+	server := newTestCert(t, "server.local", ca)
+	client := newTestCert(t, "client", ca)
+	untrusted := newTestCert(t, "client", otherCA)
+
+	serverCfg, err := NewServerConfig(Options{Cert: server.certPEM, Key: server.keyPEM, CA: ca.certPEM, ClientAuth: true})
+	require.NoError(t, err)
+
+	tt := []struct {
+		Name      string
+		Client    Options
+		ServerErr bool
+		ClientErr bool
+	}{
+		{
+			Name:   "trusted client",
+			Client: Options{Cert: client.certPEM, Key: client.keyPEM, CA: ca.certPEM, ServerName: "server.local"},
+		},
+		{
+			Name:      "untrusted client",
+			Client:    Options{Cert: untrusted.certPEM, Key: untrusted.keyPEM, CA: ca.certPEM, ServerName: "server.local"},
+			ServerErr: true,
+			ClientErr: true,
+		},
+		{
+			Name:      "no client certificate",
+			Client:    Options{CA: ca.certPEM, ServerName: "server.local"},
+			ServerErr: true,
+			ClientErr: true,
+		},
+		{
+			Name:      "wrong server name",
+			Client:    Options{Cert: client.certPEM, Key: client.keyPEM, CA: ca.certPEM, ServerName: "other.local"},
+			ServerErr: true,
+			ClientErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			clientCfg, err := NewClientConfig(tc.Client)
+			require.NoError(t, err)
+
+			serverErr, clientErr := handshake(t, serverCfg, clientCfg)
+			assert.Equal(t, tc.ServerErr, serverErr != nil, "server: %v", serverErr)
+			assert.Equal(t, tc.ClientErr, clientErr != nil, "client: %v", clientErr)
+		})
+	}
+}
+
+func TestMutualTLS_GRPC(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server.local", ca)
+	client := newTestCert(t, "client", ca)
+
+	serverCfg, err := NewServerConfig(Options{Cert: server.certPEM, Key: server.keyPEM, CA: ca.certPEM, ClientAuth: true})
+	require.NoError(t, err)
+	clientCfg, err := NewClientConfig(Options{Cert: client.certPEM, Key: client.keyPEM, CA: ca.certPEM, ServerName: "server.local"})
+	require.NoError(t, err)
+
+	protocol := make(chan string, 1)
+	srv := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(serverCfg)),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			p, _ := peer.FromContext(ctx)
+			protocol <- p.AuthInfo.(credentials.TLSInfo).State.NegotiatedProtocol
+			return handler(ctx, req)
+		}),
+	)
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(clientCfg)))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+	assert.Equal(t, "h2", <-protocol)
+}
+
+func TestReload(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	first := newTestCert(t, "first", ca)
+	second := newTestCert(t, "second", ca)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, []byte(first.certPEM), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, []byte(first.keyPEM), 0o600))
+
+	var reloadErr error
+	l, err := newLoader(Options{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Nanosecond,
+		OnReloadError:  func(err error) { reloadErr = err },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, first.cert.Raw, l.certificate().Certificate[0])
+
+	// a broken pair keeps the current certificate
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.WriteFile(certFile, []byte(second.certPEM), 0o600))
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	assert.Equal(t, first.cert.Raw, l.certificate().Certificate[0])
+	assert.Error(t, reloadErr)
+
+	require.NoError(t, os.WriteFile(keyFile, []byte(second.keyPEM), 0o600))
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	assert.Equal(t, second.cert.Raw, l.certificate().Certificate[0])
+}
+
+func TestNewServerConfig_Validation(t *testing.T) {
+	_, err := NewServerConfig(Options{})
+	assert.EqualError(t, err, "tls: server requires a certificate and key")
+
+	ca := newTestCert(t, "ca", nil)
+	_, err = NewServerConfig(Options{Cert: ca.certPEM, Key: ca.keyPEM, ClientAuth: true})
+	assert.EqualError(t, err, "tls: client auth requires a CA")
+
+	_, err = NewServerConfig(Options{CertFile: "/does/not/exist", Key: ca.keyPEM})
+	assert.Error(t, err)
+
+	_, err = NewServerConfig(Options{Cert: ca.certPEM})
+	assert.EqualError(t, err, "tls: certificate and key must be set together")
+
+	certPEM, keyPEM := newEd25519Cert(t, time.Now().Add(-time.Minute))
+	_, err = NewServerConfig(Options{Cert: certPEM, Key: keyPEM})
+	assert.ErrorContains(t, err, `tls: certificate "ed25519" expired at`)
+}
+
+// newEd25519Cert returns a self signed certificate with a PKCS#8 key
+func newEd25519Cert(t *testing.T, notAfter time.Time) (string, string) {
+	t.Helper()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ed25519"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestReload_BeforeExpiry(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	write := func(notAfter time.Time) []byte {
+		certPEM, keyPEM := newEd25519Cert(t, notAfter)
+		require.NoError(t, os.WriteFile(certFile, []byte(certPEM), 0o600))
+		require.NoError(t, os.WriteFile(keyFile, []byte(keyPEM), 0o600))
+		// tools that sync secrets do not always update the modification time
+		epoch := time.Unix(0, 0)
+		require.NoError(t, os.Chtimes(certFile, epoch, epoch))
+		require.NoError(t, os.Chtimes(keyFile, epoch, epoch))
+		block, _ := pem.Decode([]byte(certPEM))
+		return block.Bytes
+	}
+
+	write(time.Now().Add(time.Hour))
+	l, err := newLoader(Options{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Nanosecond,
+		RenewBefore:    2 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	renewed := write(time.Now().Add(72 * time.Hour))
+	assert.Equal(t, renewed, l.certificate().Certificate[0])
+
+	// far from its expiry an unchanged file is not reloaded
+	write(time.Now().Add(96 * time.Hour))
+	assert.Equal(t, renewed, l.certificate().Certificate[0])
+}